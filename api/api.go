@@ -1,11 +1,13 @@
 package api
 
 import (
-	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -13,16 +15,25 @@ import (
 	"github.com/miekg/dns"
 	"github.com/muka/ddns/db"
 	ddns "github.com/muka/ddns/dns"
+	"github.com/muka/ddns/health"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 const defaultTTL = 0
 
-type ddnsServer struct{}
+type ddnsServer struct {
+	db  *db.DB
+	dns *ddns.Handler
+	// stopping is closed on shutdown, to end the streams
+	stopping chan struct{}
+}
 
-func newDDNSServer() DDNSServiceServer {
-	return new(ddnsServer)
+func newDDNSServer(d *db.DB) *ddnsServer {
+	return &ddnsServer{db: d, dns: ddns.NewHandler(d), stopping: make(chan struct{})}
 }
 
 func getRecord(msg *Record) (rr dns.RR) {
@@ -83,123 +94,601 @@ func getRecord(msg *Record) (rr dns.RR) {
 func (s *ddnsServer) DeleteRecord(ctx context.Context, msg *Record) (*Record, error) {
 	log.Debugf("Delete request %s %s", msg.GetType(), msg.GetDomain())
 
+	msg.IfMatch = ifMatch(ctx, msg)
+
+	err := s.db.Update(origin(ctx), func(tx *db.Tx) error {
+		return deleteRecord(tx, msg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// deleteRecord remove a record set, or a single value if the ip is set
+func deleteRecord(tx *db.Tx, msg *Record) error {
+
 	if msg.GetDomain() == "" {
-		return nil, errors.New("Domain is missing")
+		return invalidArgument("domain", "Domain is missing")
 	}
 
+	domain, err := ddns.CanonicalName(msg.GetDomain())
+	if err != nil {
+		return invalidArgument("domain", err.Error())
+	}
+	msg.Domain = domain
+	msg.UnicodeDomain = ddns.UnicodeName(domain)
+
 	if msg.GetType() == "" {
-		return nil, errors.New("Type is missing")
+		return invalidArgument("type", "Type is missing")
 	}
 
 	rr := getRecord(msg)
+	if rr == nil {
+		return invalidArgument("type", "Record type not supported (Use one of A, AAAA, MX, CNAME)")
+	}
+
 	key, err := ddns.GetKey(rr.Header().Name, rr.Header().Rrtype)
 	if err != nil {
-		return nil, err
+		return invalidArgument("domain", err.Error())
+	}
+
+	if msg.GetIfMatch() != "" {
+		record, err := tx.GetRecord(key)
+		if err != nil && !db.IsNotFound(err) {
+			return err
+		}
+		if err := checkVersion(msg.GetIfMatch(), record, err == nil); err != nil {
+			return err
+		}
+	}
+
+	if msg.GetIp() != "" {
+		// Remove a single record from the set
+		record, err := tx.GetRecord(key)
+		if err != nil {
+			return err
+		}
+		found, err := ddns.RemoveRecordValue(&record, rr)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return status.Error(codes.NotFound, "Record value not found: "+msg.GetIp())
+		}
+		if len(record.Values) > 0 {
+			msg.Id, err = tx.StoreRecord(key, record)
+			return err
+		}
 	}
 
-	err1 := db.DeleteRecord(key)
-	if err1 != nil {
-		return nil, err1
+	err = tx.DeleteRecord(key)
+	if err != nil {
+		return err
 	}
 
 	key, err = ddns.GetKey(rr.Header().Name, dns.TypeCNAME)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	ptr, err := db.GetRecord(key)
+	_, err = tx.GetRecord(key)
+	if err != nil && !db.IsNotFound(err) {
+		return err
+	}
 
-	if err != nil {
+	if err == nil {
+		return tx.DeleteRecord(key)
+	}
+
+	return nil
+}
+
+func (s *ddnsServer) SaveRecord(ctx context.Context, msg *Record) (*Record, error) {
+
+	log.Debugf("Save request: %s %s %s", msg.GetType(), msg.GetDomain(), msg.GetIp())
+
+	if err := setCallerIP(ctx, msg); err != nil {
 		return nil, err
 	}
+	msg.IfMatch = ifMatch(ctx, msg)
 
-	if &ptr != nil {
-		err1 = db.DeleteRecord(key)
-		if err1 != nil {
-			return nil, err1
-		}
+	err := s.db.Update(origin(ctx), func(tx *db.Tx) error {
+		return saveRecord(tx, msg)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return msg, nil
 }
 
-func (s *ddnsServer) SaveRecord(ctx context.Context, msg *Record) (*Record, error) {
+// setCallerIP set the record address to the caller one, if requested
+func setCallerIP(ctx context.Context, msg *Record) error {
 
-	log.Debugf("Save request: %s %s %s", msg.GetType(), msg.GetDomain(), msg.GetIp())
+	if !msg.GetUseCallerIp() {
+		return nil
+	}
+
+	ip, err := callerIP(ctx)
+	if err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	rtype := "A"
+	if ip.To4() == nil {
+		rtype = "AAAA"
+	}
+
+	switch strings.ToUpper(msg.GetType()) {
+	case "":
+		msg.Type = rtype
+	case rtype:
+	default:
+		return invalidArgument("type", "Caller IP "+ip.String()+" does not match type "+msg.GetType())
+	}
+
+	log.Debugf("Using caller IP %s", ip.String())
+	msg.Ip = ip.String()
+
+	return nil
+}
+
+// saveRecord store a record set, its PTR and lease
+func saveRecord(tx *db.Tx, msg *Record) error {
 
 	if msg.GetIp() == "" {
-		return nil, errors.New("IP is missing")
+		return invalidArgument("ip", "IP is missing")
 	}
 
 	ipaddress := net.ParseIP(msg.GetIp())
 	if ipaddress == nil {
-		return nil, errors.New("Cannot parse IP")
+		return invalidArgument("ip", "Cannot parse IP")
 	}
 
 	if msg.GetDomain() == "" {
-		return nil, errors.New("Domain is missing")
+		return invalidArgument("domain", "Domain is missing")
 	}
 
+	domain, err := ddns.CanonicalName(msg.GetDomain())
+	if err != nil {
+		return invalidArgument("domain", err.Error())
+	}
+	msg.Domain = domain
+	msg.UnicodeDomain = ddns.UnicodeName(domain)
+
 	rr := getRecord(msg)
+	if rr == nil {
+		return invalidArgument("type", "Record type not supported (Use one of A, AAAA, MX, CNAME)")
+	}
 	fmt.Println(rr)
 	rtype := rr.Header().Rrtype
 
-	if rtype == 0 {
-		return nil, errors.New("Record type not supported (Use one of A, AAAA, MX, CNAME)")
+	key, err := ddns.GetKey(msg.GetDomain(), rtype)
+	if err != nil {
+		return invalidArgument("domain", err.Error())
+	}
+
+	policy, err := ddns.ParsePolicy(msg.GetPolicy())
+	if err != nil {
+		return invalidArgument("policy", err.Error())
+	}
+
+	var check *db.HealthCheck
+	if msg.GetCheck() != nil {
+		check, err = health.ParseCheck(db.HealthCheck{
+			Type:      msg.GetCheck().GetType(),
+			Port:      int(msg.GetCheck().GetPort()),
+			Path:      msg.GetCheck().GetPath(),
+			Status:    int(msg.GetCheck().GetStatus()),
+			Timeout:   int(msg.GetCheck().GetTimeout()),
+			Threshold: int(msg.GetCheck().GetThreshold()),
+		})
+		if err != nil {
+			return invalidArgument("check", err.Error())
+		}
+	}
+
+	record, err := tx.GetRecord(key)
+	if err != nil && !db.IsNotFound(err) {
+		return err
+	}
+
+	if err := checkVersion(msg.GetIfMatch(), record, err == nil); err != nil {
+		return err
+	}
+
+	if err == nil && msg.GetPolicy() == "" {
+		// keep the policy of the existing set
+		policy = record.Policy
+	}
+	if err == nil && msg.GetCheck() == nil {
+		// keep the health check of the existing set
+		check = record.Check
+	}
+
+	expires := msg.GetExpires()
+	leaseID := ""
+	if msg.GetLease() > 0 {
+		leaseID = msg.GetLeaseId()
+		if leaseID == "" {
+			leaseID = db.NewLeaseID()
+		}
+		expires = time.Now().Unix() + int64(msg.GetLease())
+	}
+
+	if err == nil && msg.GetAppend() {
+		err = ddns.AddRecordValue(&record, rr, int(msg.GetWeight()))
+		if err != nil {
+			return err
+		}
+		if msg.GetLease() == 0 && msg.GetExpires() == 0 {
+			// keep the expiration and the lease of the existing set
+			expires = record.Expires
+			leaseID = record.LeaseID
+		}
+		record.Expires = expires
+	} else {
+		record = db.NewRecord(rr.String(), expires)
+		record.Values[0].Weight = int(msg.GetWeight())
+	}
+	record.Policy = policy
+	record.Check = check
+	record.LeaseID = leaseID
+
+	msg.Id, err = tx.StoreRecord(key, record)
+
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+
+	if msg.GetPTR() {
+		// Add PTR record
+		ptrKey, ptr, err := ddns.NewPTRRecord(msg.GetIp(), msg.GetDomain(), rr.Header().Ttl, expires, leaseID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.StoreRecord(ptrKey, ptr); err != nil {
+			return err
+		}
+		keys = append(keys, ptrKey)
+	}
+
+	if leaseID != "" {
+		err = tx.StoreLease(leaseID, int64(msg.GetLease()), keys...)
+		if err != nil {
+			return err
+		}
+		msg.LeaseId = leaseID
+	}
+
+	return nil
+}
+
+func (s *ddnsServer) RenewLease(ctx context.Context, msg *Lease) (*Lease, error) {
+	log.Debugf("Renew lease %s", msg.GetId())
+
+	if msg.GetId() == "" {
+		return nil, invalidArgument("id", "Lease ID is missing")
+	}
+
+	if msg.GetDuration() < 0 {
+		return nil, invalidArgument("duration", "Lease duration cannot be negative")
+	}
+
+	expires, err := s.db.RenewLease(msg.GetId(), int64(msg.GetDuration()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Lease{
+		Id:       msg.GetId(),
+		Duration: msg.GetDuration(),
+		Expires:  expires,
+	}, nil
+}
+
+func (s *ddnsServer) GetRecord(ctx context.Context, msg *Record) (*RecordSet, error) {
+	log.Debugf("Get request %s %s", msg.GetType(), msg.GetDomain())
+
+	if msg.GetDomain() == "" {
+		return nil, invalidArgument("domain", "Domain is missing")
+	}
+
+	rtype, ok := dns.StringToType[strings.ToUpper(msg.GetType())]
+	if !ok {
+		return nil, invalidArgument("type", "Record type not supported: "+msg.GetType())
 	}
 
 	key, err := ddns.GetKey(msg.GetDomain(), rtype)
+	if err != nil {
+		return nil, invalidArgument("domain", err.Error())
+	}
+
+	record, err := s.db.GetRecord(key)
 	if err != nil {
 		return nil, err
 	}
 
-	record := db.NewRecord(rr.String(), int64(msg.GetExpires()))
-	err = db.StoreRecord(key, record)
+	domain, _ := ddns.CanonicalName(msg.GetDomain())
+	return recordSet(key, domain, rtype, record), nil
+}
+
+func (s *ddnsServer) ListRecords(ctx context.Context, msg *ListRecordsRequest) (*RecordList, error) {
+
+	suffix := ""
+	if msg.GetDomain() != "" {
+		domain, err := ddns.CanonicalName(msg.GetDomain())
+		if err != nil {
+			return nil, invalidArgument("domain", err.Error())
+		}
+		suffix = domain
+	}
 
+	var rtype uint16
+	if msg.GetType() != "" {
+		t, ok := dns.StringToType[strings.ToUpper(msg.GetType())]
+		if !ok {
+			return nil, invalidArgument("type", "Record type not supported: "+msg.GetType())
+		}
+		rtype = t
+	}
+
+	records, err := s.db.GetRecords()
 	if err != nil {
 		return nil, err
 	}
 
-	if msg.GetPTR() {
-		// Add PTR record
-		err := ddns.AddPTRRecord(msg.GetIp(), msg.GetDomain(), rr.Header().Ttl, int64(msg.GetExpires()))
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := &RecordList{}
+	for _, key := range keys {
+
+		domain, t, err := ddns.ParseKey(key)
 		if err != nil {
-			return nil, err
+			log.Warnf("Skipping record %s: %s", key, err.Error())
+			continue
+		}
+		if rtype != 0 && t != rtype {
+			continue
+		}
+		if suffix != "" && domain != suffix && !strings.HasSuffix(domain, "."+suffix) {
+			continue
 		}
+
+		list.Records = append(list.Records, recordSet(key, domain, t, records[key]))
 	}
 
-	return msg, nil
+	return list, nil
 }
 
-//Run start the server
-func Run(iface string) error {
+// recordSet return a stored record set with the health of its values
+func recordSet(key string, domain string, rtype uint16, record db.Record) *RecordSet {
+
+	set := &RecordSet{
+		Id:            record.ID,
+		Domain:        domain,
+		UnicodeDomain: ddns.UnicodeName(domain),
+		Type:          dns.TypeToString[rtype],
+		Expires:       record.Expires,
+		Policy:        record.Policy,
+		LeaseId:       record.LeaseID,
+	}
+
+	if record.Check != nil {
+		set.Check = &HealthCheck{
+			Type:      record.Check.Type,
+			Port:      int32(record.Check.Port),
+			Path:      record.Check.Path,
+			Status:    int32(record.Check.Status),
+			Timeout:   int32(record.Check.Timeout),
+			Threshold: int32(record.Check.Threshold),
+		}
+	}
+
+	for _, value := range record.GetValues() {
+		v := &RecordValue{
+			Value:   value.RR,
+			Weight:  int32(value.Weight),
+			Healthy: true,
+		}
+		if status, ok := health.GetStatus(key, value.RR); ok {
+			v.Healthy = status.Healthy
+			v.Error = status.Error
+			v.LastCheck = status.LastCheck.Unix()
+		}
+		set.Values = append(set.Values, v)
+	}
+
+	return set
+}
+
+//GRPCServer serve the gRPC API, and the in-process connection of the JSON gateway
+type GRPCServer struct {
+	listener net.Listener
+	service  *ddnsServer
+	server   *grpc.Server
+	local    *grpc.Server
+	once     sync.Once
+}
+
+//Listen bind the gRPC API, serve it with Serve
+func Listen(iface string, d *db.DB) (*GRPCServer, error) {
 	log.Debugf("Listening gRPC service at %s", iface)
 	listen, err := net.Listen("tcp", iface)
+	if err != nil {
+		return nil, err
+	}
+
+	service := newDDNSServer(d)
+
+	// the JSON gateway reach the service in-process, without TLS
+	local := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(errorInterceptor, auditInterceptor, service.authInterceptor, replicaInterceptor),
+		grpc.ChainStreamInterceptor(errorStreamInterceptor, auditStreamInterceptor, service.authStreamInterceptor),
+	)
+	RegisterDDNSServiceServer(local, service)
+	go local.Serve(inprocess)
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(errorInterceptor, auditInterceptor, service.authInterceptor, replicaInterceptor),
+		grpc.ChainStreamInterceptor(errorStreamInterceptor, auditStreamInterceptor, service.authStreamInterceptor),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+	RegisterDDNSServiceServer(server, service)
+
+	return &GRPCServer{listener: listen, service: service, server: server, local: local}, nil
+}
+
+//Serve the gRPC API until shut down
+func (s *GRPCServer) Serve() error {
+	err := s.server.Serve(s.listener)
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
+	return err
+}
+
+//Shutdown stop accepting requests and wait the in-flight ones, the pending
+// requests are dropped when the context is done. The replication streams end
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+
+	s.once.Do(func() {
+		close(s.service.stopping)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		s.local.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		s.local.Stop()
+		return ctx.Err()
+	}
+}
+
+//Run start the server
+func Run(iface string, d *db.DB) error {
+	server, err := Listen(iface, d)
 	if err != nil {
 		return err
 	}
-	server := grpc.NewServer()
-	RegisterDDNSServiceServer(server, newDDNSServer())
-	server.Serve(listen)
-	return nil
+	return server.Serve()
 }
 
-// RunEndPoint start the JSON restful api
-func RunEndPoint(grpcAddress string, address string, opts ...runtime.ServeMuxOption) error {
+//HTTPServer serve the JSON API
+type HTTPServer struct {
+	listener net.Listener
+	server   *http.Server
+	conn     *grpc.ClientConn
+	cancel   context.CancelFunc
+}
+
+//ListenEndPoint bind the JSON restful api, serve it with Serve
+func ListenEndPoint(address string, d *db.DB, opts ...runtime.ServeMuxOption) (*HTTPServer, error) {
 
 	log.Debugf("Starting JSON API %s", address)
 
-	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	ctx, cancel := context.WithCancel(context.Background())
 
+	// the Authorization header is forwarded by the gateway to the gRPC service
+	opts = append(opts,
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMetadata(gatewayMetadata),
+		runtime.WithForwardResponseOption(gatewayETag),
+		runtime.WithProtoErrorHandler(gatewayError),
+	)
 	mux := runtime.NewServeMux(opts...)
-	dialOpts := []grpc.DialOption{grpc.WithInsecure()}
-	err := RegisterDDNSServiceHandlerFromEndpoint(ctx, mux, grpcAddress, dialOpts)
+	dialOpts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(inprocess.dial),
+	}
+	conn, err := grpc.DialContext(ctx, inprocessAddress, dialOpts...)
 	if err != nil {
-		return err
+		cancel()
+		return nil, err
 	}
 
-	http.ListenAndServe(address, mux)
-	return nil
+	err = RegisterDDNSServiceHandler(ctx, mux, conn)
+	if err != nil {
+		conn.Close()
+		cancel()
+		return nil, err
+	}
+
+	handler := http.NewServeMux()
+	handler.Handle("/", mux)
+	handler.Handle("/nic/update", dyndnsUpdate(newDDNSServer(d)))
+	handler.Handle("/v1/backup", backupHandler(NewDDNSServiceClient(conn)))
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		conn.Close()
+		cancel()
+		return nil, err
+	}
+
+	server := &http.Server{
+		Addr:      address,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+
+	return &HTTPServer{listener: listener, server: server, conn: conn, cancel: cancel}, nil
+}
+
+//Serve the JSON API until shut down
+func (s *HTTPServer) Serve() error {
+
+	var err error
+	if s.server.TLSConfig != nil {
+		err = s.server.ServeTLS(s.listener, "", "")
+	} else {
+		err = s.server.Serve(s.listener)
+	}
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+//Shutdown stop accepting requests and wait the in-flight ones until the context is done
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+
+	defer s.cancel()
+	defer s.conn.Close()
+
+	err := s.server.Shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		s.server.Close()
+	}
+	return err
+}
+
+// RunEndPoint start the JSON restful api
+func RunEndPoint(address string, d *db.DB, opts ...runtime.ServeMuxOption) error {
+	server, err := ListenEndPoint(address, d, opts...)
+	if err != nil {
+		return err
+	}
+	return server.Serve()
 }
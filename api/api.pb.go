@@ -38,11 +38,31 @@ type Record struct {
 	Type string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
 	// Expiration of the record, after which will be removed.
 	// Default is 0 for not expiring
-	Expires int32 `protobuf:"varint,5,opt,name=expires,proto3" json:"expires,omitempty"`
+	Expires int64 `protobuf:"varint,5,opt,name=expires,proto3" json:"expires,omitempty"`
 	// TTL time to live of the record
 	TTL int32 `protobuf:"varint,6,opt,name=TTL,proto3" json:"TTL,omitempty"`
 	// Add a PTR (reverse) record
-	PTR                  bool     `protobuf:"varint,7,opt,name=PTR,proto3" json:"PTR,omitempty"`
+	PTR bool `protobuf:"varint,7,opt,name=PTR,proto3" json:"PTR,omitempty"`
+	// Weight of the record when using the weighted policy
+	Weight int32 `protobuf:"varint,8,opt,name=weight,proto3" json:"weight,omitempty"`
+	// Answer policy of the record set, one of fixed, round-robin, random, weighted
+	Policy string `protobuf:"bytes,9,opt,name=policy,proto3" json:"policy,omitempty"`
+	// Add the record to the existing set instead of replacing it
+	Append bool `protobuf:"varint,10,opt,name=append,proto3" json:"append,omitempty"`
+	// Health check of the record set
+	Check *HealthCheck `protobuf:"bytes,11,opt,name=check,proto3" json:"check,omitempty"`
+	// Lease duration in seconds, the record is removed unless the lease is renewed
+	Lease int32 `protobuf:"varint,12,opt,name=lease,proto3" json:"lease,omitempty"`
+	// Lease ID, returned when a lease is set. Pass it to attach the record to an existing lease
+	LeaseId string `protobuf:"bytes,13,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	// Use the address of the caller as ip, the type is set to A or AAAA by address family
+	UseCallerIp bool `protobuf:"varint,14,opt,name=use_caller_ip,json=useCallerIp,proto3" json:"use_caller_ip,omitempty"`
+	// Version (id) the stored record must match, * for any existing record.
+	// Set from the If-Match header when using the HTTP API
+	IfMatch string `protobuf:"bytes,15,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`
+	// Record Name with the internationalized labels in Unicode (U-label),
+	// domain is returned in punycode (A-label)
+	UnicodeDomain        string   `protobuf:"bytes,16,opt,name=unicode_domain,json=unicodeDomain,proto3" json:"unicode_domain,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -101,7 +121,7 @@ func (m *Record) GetType() string {
 	return ""
 }
 
-func (m *Record) GetExpires() int32 {
+func (m *Record) GetExpires() int64 {
 	if m != nil {
 		return m.Expires
 	}
@@ -122,31 +142,2040 @@ func (m *Record) GetPTR() bool {
 	return false
 }
 
+func (m *Record) GetWeight() int32 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *Record) GetPolicy() string {
+	if m != nil {
+		return m.Policy
+	}
+	return ""
+}
+
+func (m *Record) GetAppend() bool {
+	if m != nil {
+		return m.Append
+	}
+	return false
+}
+
+func (m *Record) GetCheck() *HealthCheck {
+	if m != nil {
+		return m.Check
+	}
+	return nil
+}
+
+func (m *Record) GetLease() int32 {
+	if m != nil {
+		return m.Lease
+	}
+	return 0
+}
+
+func (m *Record) GetLeaseId() string {
+	if m != nil {
+		return m.LeaseId
+	}
+	return ""
+}
+
+func (m *Record) GetUseCallerIp() bool {
+	if m != nil {
+		return m.UseCallerIp
+	}
+	return false
+}
+
+func (m *Record) GetIfMatch() string {
+	if m != nil {
+		return m.IfMatch
+	}
+	return ""
+}
+
+func (m *Record) GetUnicodeDomain() string {
+	if m != nil {
+		return m.UnicodeDomain
+	}
+	return ""
+}
+
+// Lease of ephemeral records
+type Lease struct {
+	// Lease ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Lease duration in seconds, default to the last duration set
+	Duration int32 `protobuf:"varint,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	// Expiration of the records as Unix timestamp
+	Expires              int64    `protobuf:"varint,3,opt,name=expires,proto3" json:"expires,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Lease) Reset()         { *m = Lease{} }
+func (m *Lease) String() string { return proto.CompactTextString(m) }
+func (*Lease) ProtoMessage()    {}
+func (*Lease) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{1}
+}
+
+func (m *Lease) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Lease.Unmarshal(m, b)
+}
+func (m *Lease) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Lease.Marshal(b, m, deterministic)
+}
+func (m *Lease) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Lease.Merge(m, src)
+}
+func (m *Lease) XXX_Size() int {
+	return xxx_messageInfo_Lease.Size(m)
+}
+func (m *Lease) XXX_DiscardUnknown() {
+	xxx_messageInfo_Lease.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Lease proto.InternalMessageInfo
+
+func (m *Lease) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Lease) GetDuration() int32 {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
+func (m *Lease) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+// Change of a record set
+type HistoryEntry struct {
+	// Version stored by the change, empty on removal
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// Version replaced by the change, empty on creation
+	PreviousVersion string   `protobuf:"bytes,2,opt,name=previous_version,json=previousVersion,proto3" json:"previous_version,omitempty"`
+	OldValues       []string `protobuf:"bytes,3,rep,name=old_values,json=oldValues,proto3" json:"old_values,omitempty"`
+	NewValues       []string `protobuf:"bytes,4,rep,name=new_values,json=newValues,proto3" json:"new_values,omitempty"`
+	// Token, certificate, TSIG key or address of the client
+	Actor string `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`
+	// One of api, nsupdate, expiry
+	Source string `protobuf:"bytes,6,opt,name=source,proto3" json:"source,omitempty"`
+	// Time of the change as Unix timestamp
+	Time                 int64    `protobuf:"varint,7,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HistoryEntry) Reset()         { *m = HistoryEntry{} }
+func (m *HistoryEntry) String() string { return proto.CompactTextString(m) }
+func (*HistoryEntry) ProtoMessage()    {}
+func (*HistoryEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{2}
+}
+
+func (m *HistoryEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HistoryEntry.Unmarshal(m, b)
+}
+func (m *HistoryEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HistoryEntry.Marshal(b, m, deterministic)
+}
+func (m *HistoryEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistoryEntry.Merge(m, src)
+}
+func (m *HistoryEntry) XXX_Size() int {
+	return xxx_messageInfo_HistoryEntry.Size(m)
+}
+func (m *HistoryEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistoryEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistoryEntry proto.InternalMessageInfo
+
+func (m *HistoryEntry) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *HistoryEntry) GetPreviousVersion() string {
+	if m != nil {
+		return m.PreviousVersion
+	}
+	return ""
+}
+
+func (m *HistoryEntry) GetOldValues() []string {
+	if m != nil {
+		return m.OldValues
+	}
+	return nil
+}
+
+func (m *HistoryEntry) GetNewValues() []string {
+	if m != nil {
+		return m.NewValues
+	}
+	return nil
+}
+
+func (m *HistoryEntry) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *HistoryEntry) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *HistoryEntry) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type RecordHistory struct {
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Type   string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Changes, the most recent first
+	Entries              []*HistoryEntry `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *RecordHistory) Reset()         { *m = RecordHistory{} }
+func (m *RecordHistory) String() string { return proto.CompactTextString(m) }
+func (*RecordHistory) ProtoMessage()    {}
+func (*RecordHistory) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{3}
+}
+
+func (m *RecordHistory) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecordHistory.Unmarshal(m, b)
+}
+func (m *RecordHistory) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecordHistory.Marshal(b, m, deterministic)
+}
+func (m *RecordHistory) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecordHistory.Merge(m, src)
+}
+func (m *RecordHistory) XXX_Size() int {
+	return xxx_messageInfo_RecordHistory.Size(m)
+}
+func (m *RecordHistory) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecordHistory.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecordHistory proto.InternalMessageInfo
+
+func (m *RecordHistory) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *RecordHistory) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *RecordHistory) GetEntries() []*HistoryEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type RestoreRequest struct {
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Type   string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Version to restore
+	Version string `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	// Version the stored record must match, * for any existing record
+	IfMatch              string   `protobuf:"bytes,4,opt,name=if_match,json=ifMatch,proto3" json:"if_match,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RestoreRequest) Reset()         { *m = RestoreRequest{} }
+func (m *RestoreRequest) String() string { return proto.CompactTextString(m) }
+func (*RestoreRequest) ProtoMessage()    {}
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{4}
+}
+
+func (m *RestoreRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreRequest.Unmarshal(m, b)
+}
+func (m *RestoreRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreRequest.Marshal(b, m, deterministic)
+}
+func (m *RestoreRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreRequest.Merge(m, src)
+}
+func (m *RestoreRequest) XXX_Size() int {
+	return xxx_messageInfo_RestoreRequest.Size(m)
+}
+func (m *RestoreRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestoreRequest proto.InternalMessageInfo
+
+func (m *RestoreRequest) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *RestoreRequest) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *RestoreRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *RestoreRequest) GetIfMatch() string {
+	if m != nil {
+		return m.IfMatch
+	}
+	return ""
+}
+
+// Entry of the audit log
+type AuditEntry struct {
+	// Time in RFC 3339 format
+	Time     string `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Actor    string `protobuf:"bytes,2,opt,name=actor,proto3" json:"actor,omitempty"`
+	ClientIp string `protobuf:"bytes,3,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"`
+	// One of api, nsupdate, expiry
+	Source string `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	Action string `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"`
+	Target string `protobuf:"bytes,6,opt,name=target,proto3" json:"target,omitempty"`
+	// One of success, failure, denied
+	Outcome string `protobuf:"bytes,7,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	Error   string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	// Hash of the previous entry
+	Prev                 string   `protobuf:"bytes,9,opt,name=prev,proto3" json:"prev,omitempty"`
+	Hash                 string   `protobuf:"bytes,10,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuditEntry) Reset()         { *m = AuditEntry{} }
+func (m *AuditEntry) String() string { return proto.CompactTextString(m) }
+func (*AuditEntry) ProtoMessage()    {}
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{5}
+}
+
+func (m *AuditEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuditEntry.Unmarshal(m, b)
+}
+func (m *AuditEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuditEntry.Marshal(b, m, deterministic)
+}
+func (m *AuditEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuditEntry.Merge(m, src)
+}
+func (m *AuditEntry) XXX_Size() int {
+	return xxx_messageInfo_AuditEntry.Size(m)
+}
+func (m *AuditEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuditEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuditEntry proto.InternalMessageInfo
+
+func (m *AuditEntry) GetTime() string {
+	if m != nil {
+		return m.Time
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetClientIp() string {
+	if m != nil {
+		return m.ClientIp
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetOutcome() string {
+	if m != nil {
+		return m.Outcome
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetPrev() string {
+	if m != nil {
+		return m.Prev
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+type AuditQuery struct {
+	// Unix timestamps limiting the entries
+	Since int64  `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	Until int64  `protobuf:"varint,2,opt,name=until,proto3" json:"until,omitempty"`
+	Actor string `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`
+	// Text included in the target
+	Target string `protobuf:"bytes,4,opt,name=target,proto3" json:"target,omitempty"`
+	Source string `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"`
+	// Max number of entries, the most recent ones
+	Limit                int32    `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuditQuery) Reset()         { *m = AuditQuery{} }
+func (m *AuditQuery) String() string { return proto.CompactTextString(m) }
+func (*AuditQuery) ProtoMessage()    {}
+func (*AuditQuery) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{6}
+}
+
+func (m *AuditQuery) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuditQuery.Unmarshal(m, b)
+}
+func (m *AuditQuery) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuditQuery.Marshal(b, m, deterministic)
+}
+func (m *AuditQuery) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuditQuery.Merge(m, src)
+}
+func (m *AuditQuery) XXX_Size() int {
+	return xxx_messageInfo_AuditQuery.Size(m)
+}
+func (m *AuditQuery) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuditQuery.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuditQuery proto.InternalMessageInfo
+
+func (m *AuditQuery) GetSince() int64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+func (m *AuditQuery) GetUntil() int64 {
+	if m != nil {
+		return m.Until
+	}
+	return 0
+}
+
+func (m *AuditQuery) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *AuditQuery) GetTarget() string {
+	if m != nil {
+		return m.Target
+	}
+	return ""
+}
+
+func (m *AuditQuery) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *AuditQuery) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type AuditLog struct {
+	Entries []*AuditEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	// False if the hash chain is broken
+	Valid                bool     `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
+	Error                string   `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuditLog) Reset()         { *m = AuditLog{} }
+func (m *AuditLog) String() string { return proto.CompactTextString(m) }
+func (*AuditLog) ProtoMessage()    {}
+func (*AuditLog) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{7}
+}
+
+func (m *AuditLog) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuditLog.Unmarshal(m, b)
+}
+func (m *AuditLog) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuditLog.Marshal(b, m, deterministic)
+}
+func (m *AuditLog) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuditLog.Merge(m, src)
+}
+func (m *AuditLog) XXX_Size() int {
+	return xxx_messageInfo_AuditLog.Size(m)
+}
+func (m *AuditLog) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuditLog.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuditLog proto.InternalMessageInfo
+
+func (m *AuditLog) GetEntries() []*AuditEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+func (m *AuditLog) GetValid() bool {
+	if m != nil {
+		return m.Valid
+	}
+	return false
+}
+
+func (m *AuditLog) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// Condition checked before applying a batch
+type Prerequisite struct {
+	// Record Name
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Record Type
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// Value that must be in the set, any value if empty
+	Ip string `protobuf:"bytes,3,opt,name=ip,proto3" json:"ip,omitempty"`
+	// One of exists (default), not_exists
+	Condition            string   `protobuf:"bytes,4,opt,name=condition,proto3" json:"condition,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Prerequisite) Reset()         { *m = Prerequisite{} }
+func (m *Prerequisite) String() string { return proto.CompactTextString(m) }
+func (*Prerequisite) ProtoMessage()    {}
+func (*Prerequisite) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{8}
+}
+
+func (m *Prerequisite) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Prerequisite.Unmarshal(m, b)
+}
+func (m *Prerequisite) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Prerequisite.Marshal(b, m, deterministic)
+}
+func (m *Prerequisite) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Prerequisite.Merge(m, src)
+}
+func (m *Prerequisite) XXX_Size() int {
+	return xxx_messageInfo_Prerequisite.Size(m)
+}
+func (m *Prerequisite) XXX_DiscardUnknown() {
+	xxx_messageInfo_Prerequisite.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Prerequisite proto.InternalMessageInfo
+
+func (m *Prerequisite) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *Prerequisite) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Prerequisite) GetIp() string {
+	if m != nil {
+		return m.Ip
+	}
+	return ""
+}
+
+func (m *Prerequisite) GetCondition() string {
+	if m != nil {
+		return m.Condition
+	}
+	return ""
+}
+
+// Change of a batch
+type BatchOperation struct {
+	// One of upsert, delete
+	Op                   string   `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	Record               *Record  `protobuf:"bytes,2,opt,name=record,proto3" json:"record,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchOperation) Reset()         { *m = BatchOperation{} }
+func (m *BatchOperation) String() string { return proto.CompactTextString(m) }
+func (*BatchOperation) ProtoMessage()    {}
+func (*BatchOperation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{9}
+}
+
+func (m *BatchOperation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchOperation.Unmarshal(m, b)
+}
+func (m *BatchOperation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchOperation.Marshal(b, m, deterministic)
+}
+func (m *BatchOperation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchOperation.Merge(m, src)
+}
+func (m *BatchOperation) XXX_Size() int {
+	return xxx_messageInfo_BatchOperation.Size(m)
+}
+func (m *BatchOperation) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchOperation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchOperation proto.InternalMessageInfo
+
+func (m *BatchOperation) GetOp() string {
+	if m != nil {
+		return m.Op
+	}
+	return ""
+}
+
+func (m *BatchOperation) GetRecord() *Record {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+type BatchRequest struct {
+	Prerequisites        []*Prerequisite   `protobuf:"bytes,1,rep,name=prerequisites,proto3" json:"prerequisites,omitempty"`
+	Operations           []*BatchOperation `protobuf:"bytes,2,rep,name=operations,proto3" json:"operations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *BatchRequest) Reset()         { *m = BatchRequest{} }
+func (m *BatchRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchRequest) ProtoMessage()    {}
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{10}
+}
+
+func (m *BatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchRequest.Unmarshal(m, b)
+}
+func (m *BatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchRequest.Marshal(b, m, deterministic)
+}
+func (m *BatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchRequest.Merge(m, src)
+}
+func (m *BatchRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchRequest.Size(m)
+}
+func (m *BatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchRequest proto.InternalMessageInfo
+
+func (m *BatchRequest) GetPrerequisites() []*Prerequisite {
+	if m != nil {
+		return m.Prerequisites
+	}
+	return nil
+}
+
+func (m *BatchRequest) GetOperations() []*BatchOperation {
+	if m != nil {
+		return m.Operations
+	}
+	return nil
+}
+
+// Result of a batch operation
+type BatchResult struct {
+	Index  int32   `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Op     string  `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	Record *Record `protobuf:"bytes,3,opt,name=record,proto3" json:"record,omitempty"`
+	// Failure of the operation, the batch is not applied
+	Error                string   `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchResult) Reset()         { *m = BatchResult{} }
+func (m *BatchResult) String() string { return proto.CompactTextString(m) }
+func (*BatchResult) ProtoMessage()    {}
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{11}
+}
+
+func (m *BatchResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResult.Unmarshal(m, b)
+}
+func (m *BatchResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResult.Marshal(b, m, deterministic)
+}
+func (m *BatchResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResult.Merge(m, src)
+}
+func (m *BatchResult) XXX_Size() int {
+	return xxx_messageInfo_BatchResult.Size(m)
+}
+func (m *BatchResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResult proto.InternalMessageInfo
+
+func (m *BatchResult) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *BatchResult) GetOp() string {
+	if m != nil {
+		return m.Op
+	}
+	return ""
+}
+
+func (m *BatchResult) GetRecord() *Record {
+	if m != nil {
+		return m.Record
+	}
+	return nil
+}
+
+func (m *BatchResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type BatchResponse struct {
+	// True if all the changes have been stored
+	Applied bool `protobuf:"varint,1,opt,name=applied,proto3" json:"applied,omitempty"`
+	// Failed prerequisites
+	Failed               []string       `protobuf:"bytes,2,rep,name=failed,proto3" json:"failed,omitempty"`
+	Results              []*BatchResult `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *BatchResponse) Reset()         { *m = BatchResponse{} }
+func (m *BatchResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchResponse) ProtoMessage()    {}
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{12}
+}
+
+func (m *BatchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResponse.Unmarshal(m, b)
+}
+func (m *BatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResponse.Marshal(b, m, deterministic)
+}
+func (m *BatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResponse.Merge(m, src)
+}
+func (m *BatchResponse) XXX_Size() int {
+	return xxx_messageInfo_BatchResponse.Size(m)
+}
+func (m *BatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResponse proto.InternalMessageInfo
+
+func (m *BatchResponse) GetApplied() bool {
+	if m != nil {
+		return m.Applied
+	}
+	return false
+}
+
+func (m *BatchResponse) GetFailed() []string {
+	if m != nil {
+		return m.Failed
+	}
+	return nil
+}
+
+func (m *BatchResponse) GetResults() []*BatchResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+// API token
+type Token struct {
+	// Token ID
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Token description
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Scopes, one of read, write, write:<domain suffix>, admin
+	Scopes []string `protobuf:"bytes,3,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	// Token secret, returned only on creation
+	Token string `protobuf:"bytes,4,opt,name=token,proto3" json:"token,omitempty"`
+	// Creation as Unix timestamp
+	Created              int64    `protobuf:"varint,5,opt,name=created,proto3" json:"created,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Token) Reset()         { *m = Token{} }
+func (m *Token) String() string { return proto.CompactTextString(m) }
+func (*Token) ProtoMessage()    {}
+func (*Token) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{13}
+}
+
+func (m *Token) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Token.Unmarshal(m, b)
+}
+func (m *Token) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Token.Marshal(b, m, deterministic)
+}
+func (m *Token) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Token.Merge(m, src)
+}
+func (m *Token) XXX_Size() int {
+	return xxx_messageInfo_Token.Size(m)
+}
+func (m *Token) XXX_DiscardUnknown() {
+	xxx_messageInfo_Token.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Token proto.InternalMessageInfo
+
+func (m *Token) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Token) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Token) GetScopes() []string {
+	if m != nil {
+		return m.Scopes
+	}
+	return nil
+}
+
+func (m *Token) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *Token) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+type ListTokensRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTokensRequest) Reset()         { *m = ListTokensRequest{} }
+func (m *ListTokensRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTokensRequest) ProtoMessage()    {}
+func (*ListTokensRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{14}
+}
+
+func (m *ListTokensRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTokensRequest.Unmarshal(m, b)
+}
+func (m *ListTokensRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTokensRequest.Marshal(b, m, deterministic)
+}
+func (m *ListTokensRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTokensRequest.Merge(m, src)
+}
+func (m *ListTokensRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTokensRequest.Size(m)
+}
+func (m *ListTokensRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTokensRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTokensRequest proto.InternalMessageInfo
+
+type TokenList struct {
+	Tokens               []*Token `protobuf:"bytes,1,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TokenList) Reset()         { *m = TokenList{} }
+func (m *TokenList) String() string { return proto.CompactTextString(m) }
+func (*TokenList) ProtoMessage()    {}
+func (*TokenList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{15}
+}
+
+func (m *TokenList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TokenList.Unmarshal(m, b)
+}
+func (m *TokenList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TokenList.Marshal(b, m, deterministic)
+}
+func (m *TokenList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TokenList.Merge(m, src)
+}
+func (m *TokenList) XXX_Size() int {
+	return xxx_messageInfo_TokenList.Size(m)
+}
+func (m *TokenList) XXX_DiscardUnknown() {
+	xxx_messageInfo_TokenList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TokenList proto.InternalMessageInfo
+
+func (m *TokenList) GetTokens() []*Token {
+	if m != nil {
+		return m.Tokens
+	}
+	return nil
+}
+
+// Health check of the values of a record set
+type HealthCheck struct {
+	// Check type, one of tcp, http or none to disable it
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Port to connect to
+	Port int32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	// HTTP request path
+	Path string `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	// Expected HTTP status, default is 200
+	Status int32 `protobuf:"varint,4,opt,name=status,proto3" json:"status,omitempty"`
+	// Timeout in seconds, default is 2
+	Timeout int32 `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Consecutive failures before marking a value unhealthy, default is 1
+	Threshold            int32    `protobuf:"varint,6,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthCheck) Reset()         { *m = HealthCheck{} }
+func (m *HealthCheck) String() string { return proto.CompactTextString(m) }
+func (*HealthCheck) ProtoMessage()    {}
+func (*HealthCheck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{16}
+}
+
+func (m *HealthCheck) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthCheck.Unmarshal(m, b)
+}
+func (m *HealthCheck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthCheck.Marshal(b, m, deterministic)
+}
+func (m *HealthCheck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthCheck.Merge(m, src)
+}
+func (m *HealthCheck) XXX_Size() int {
+	return xxx_messageInfo_HealthCheck.Size(m)
+}
+func (m *HealthCheck) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthCheck.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthCheck proto.InternalMessageInfo
+
+func (m *HealthCheck) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *HealthCheck) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *HealthCheck) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *HealthCheck) GetStatus() int32 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *HealthCheck) GetTimeout() int32 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *HealthCheck) GetThreshold() int32 {
+	if m != nil {
+		return m.Threshold
+	}
+	return 0
+}
+
+// A single value of a record set
+type RecordValue struct {
+	// Record value in zone file format
+	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// Weight of the record when using the weighted policy
+	Weight int32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	// Health check result, true if not checked
+	Healthy bool `protobuf:"varint,3,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	// Last health check error
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// Last health check as Unix timestamp
+	LastCheck            int64    `protobuf:"varint,5,opt,name=last_check,json=lastCheck,proto3" json:"last_check,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RecordValue) Reset()         { *m = RecordValue{} }
+func (m *RecordValue) String() string { return proto.CompactTextString(m) }
+func (*RecordValue) ProtoMessage()    {}
+func (*RecordValue) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{17}
+}
+
+func (m *RecordValue) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecordValue.Unmarshal(m, b)
+}
+func (m *RecordValue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecordValue.Marshal(b, m, deterministic)
+}
+func (m *RecordValue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecordValue.Merge(m, src)
+}
+func (m *RecordValue) XXX_Size() int {
+	return xxx_messageInfo_RecordValue.Size(m)
+}
+func (m *RecordValue) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecordValue.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecordValue proto.InternalMessageInfo
+
+func (m *RecordValue) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *RecordValue) GetWeight() int32 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *RecordValue) GetHealthy() bool {
+	if m != nil {
+		return m.Healthy
+	}
+	return false
+}
+
+func (m *RecordValue) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *RecordValue) GetLastCheck() int64 {
+	if m != nil {
+		return m.LastCheck
+	}
+	return 0
+}
+
+// Records stored for a domain and type
+type RecordSet struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Record Name
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Record Type
+	Type string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	// Expiration of the records
+	Expires int64 `protobuf:"varint,4,opt,name=expires,proto3" json:"expires,omitempty"`
+	// Answer policy of the record set
+	Policy string `protobuf:"bytes,5,opt,name=policy,proto3" json:"policy,omitempty"`
+	// Health check of the record set
+	Check *HealthCheck `protobuf:"bytes,6,opt,name=check,proto3" json:"check,omitempty"`
+	// Records of the set
+	Values []*RecordValue `protobuf:"bytes,7,rep,name=values,proto3" json:"values,omitempty"`
+	// Lease ID of the record set
+	LeaseId string `protobuf:"bytes,8,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	// Record Name with the internationalized labels in Unicode (U-label),
+	// domain is returned in punycode (A-label)
+	UnicodeDomain        string   `protobuf:"bytes,9,opt,name=unicode_domain,json=unicodeDomain,proto3" json:"unicode_domain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RecordSet) Reset()         { *m = RecordSet{} }
+func (m *RecordSet) String() string { return proto.CompactTextString(m) }
+func (*RecordSet) ProtoMessage()    {}
+func (*RecordSet) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{18}
+}
+
+func (m *RecordSet) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecordSet.Unmarshal(m, b)
+}
+func (m *RecordSet) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecordSet.Marshal(b, m, deterministic)
+}
+func (m *RecordSet) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecordSet.Merge(m, src)
+}
+func (m *RecordSet) XXX_Size() int {
+	return xxx_messageInfo_RecordSet.Size(m)
+}
+func (m *RecordSet) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecordSet.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecordSet proto.InternalMessageInfo
+
+func (m *RecordSet) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *RecordSet) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *RecordSet) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *RecordSet) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+func (m *RecordSet) GetPolicy() string {
+	if m != nil {
+		return m.Policy
+	}
+	return ""
+}
+
+func (m *RecordSet) GetCheck() *HealthCheck {
+	if m != nil {
+		return m.Check
+	}
+	return nil
+}
+
+func (m *RecordSet) GetValues() []*RecordValue {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func (m *RecordSet) GetLeaseId() string {
+	if m != nil {
+		return m.LeaseId
+	}
+	return ""
+}
+
+func (m *RecordSet) GetUnicodeDomain() string {
+	if m != nil {
+		return m.UnicodeDomain
+	}
+	return ""
+}
+
+// Filter of the listed record sets
+type ListRecordsRequest struct {
+	// Domain the names end with, Unicode or punycode
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Record Type
+	Type                 string   `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRecordsRequest) Reset()         { *m = ListRecordsRequest{} }
+func (m *ListRecordsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRecordsRequest) ProtoMessage()    {}
+func (*ListRecordsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{19}
+}
+
+func (m *ListRecordsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRecordsRequest.Unmarshal(m, b)
+}
+func (m *ListRecordsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRecordsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListRecordsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRecordsRequest.Merge(m, src)
+}
+func (m *ListRecordsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRecordsRequest.Size(m)
+}
+func (m *ListRecordsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRecordsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRecordsRequest proto.InternalMessageInfo
+
+func (m *ListRecordsRequest) GetDomain() string {
+	if m != nil {
+		return m.Domain
+	}
+	return ""
+}
+
+func (m *ListRecordsRequest) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+type RecordList struct {
+	Records              []*RecordSet `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *RecordList) Reset()         { *m = RecordList{} }
+func (m *RecordList) String() string { return proto.CompactTextString(m) }
+func (*RecordList) ProtoMessage()    {}
+func (*RecordList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{20}
+}
+
+func (m *RecordList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecordList.Unmarshal(m, b)
+}
+func (m *RecordList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecordList.Marshal(b, m, deterministic)
+}
+func (m *RecordList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecordList.Merge(m, src)
+}
+func (m *RecordList) XXX_Size() int {
+	return xxx_messageInfo_RecordList.Size(m)
+}
+func (m *RecordList) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecordList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecordList proto.InternalMessageInfo
+
+func (m *RecordList) GetRecords() []*RecordSet {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+// Backup request, the snapshot of the whole database is returned
+type BackupRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BackupRequest) Reset()         { *m = BackupRequest{} }
+func (m *BackupRequest) String() string { return proto.CompactTextString(m) }
+func (*BackupRequest) ProtoMessage()    {}
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{21}
+}
+
+func (m *BackupRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BackupRequest.Unmarshal(m, b)
+}
+func (m *BackupRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BackupRequest.Marshal(b, m, deterministic)
+}
+func (m *BackupRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BackupRequest.Merge(m, src)
+}
+func (m *BackupRequest) XXX_Size() int {
+	return xxx_messageInfo_BackupRequest.Size(m)
+}
+func (m *BackupRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BackupRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BackupRequest proto.InternalMessageInfo
+
+// Part of a database snapshot
+type BackupChunk struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BackupChunk) Reset()         { *m = BackupChunk{} }
+func (m *BackupChunk) String() string { return proto.CompactTextString(m) }
+func (*BackupChunk) ProtoMessage()    {}
+func (*BackupChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{22}
+}
+
+func (m *BackupChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BackupChunk.Unmarshal(m, b)
+}
+func (m *BackupChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BackupChunk.Marshal(b, m, deterministic)
+}
+func (m *BackupChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BackupChunk.Merge(m, src)
+}
+func (m *BackupChunk) XXX_Size() int {
+	return xxx_messageInfo_BackupChunk.Size(m)
+}
+func (m *BackupChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_BackupChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BackupChunk proto.InternalMessageInfo
+
+func (m *BackupChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+// Replication request of a replica
+type ReplicateRequest struct {
+	// Name of the replica, for the logs
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicateRequest) Reset()         { *m = ReplicateRequest{} }
+func (m *ReplicateRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicateRequest) ProtoMessage()    {}
+func (*ReplicateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{23}
+}
+
+func (m *ReplicateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicateRequest.Unmarshal(m, b)
+}
+func (m *ReplicateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicateRequest.Marshal(b, m, deterministic)
+}
+func (m *ReplicateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicateRequest.Merge(m, src)
+}
+func (m *ReplicateRequest) XXX_Size() int {
+	return xxx_messageInfo_ReplicateRequest.Size(m)
+}
+func (m *ReplicateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicateRequest proto.InternalMessageInfo
+
+func (m *ReplicateRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// Change sent to the replicas
+type ReplicationEvent struct {
+	// One of snapshot, snapshot_done, change, heartbeat
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Sequence of the change on the primary, or the last one for snapshot_done and heartbeat
+	Seq uint64 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	// Commit time of the change as Unix timestamp in nanoseconds
+	Time                 int64    `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Bucket               string   `protobuf:"bytes,4,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Key                  string   `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	Value                []byte   `protobuf:"bytes,6,opt,name=value,proto3" json:"value,omitempty"`
+	Deleted              bool     `protobuf:"varint,7,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicationEvent) Reset()         { *m = ReplicationEvent{} }
+func (m *ReplicationEvent) String() string { return proto.CompactTextString(m) }
+func (*ReplicationEvent) ProtoMessage()    {}
+func (*ReplicationEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{24}
+}
+
+func (m *ReplicationEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicationEvent.Unmarshal(m, b)
+}
+func (m *ReplicationEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicationEvent.Marshal(b, m, deterministic)
+}
+func (m *ReplicationEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationEvent.Merge(m, src)
+}
+func (m *ReplicationEvent) XXX_Size() int {
+	return xxx_messageInfo_ReplicationEvent.Size(m)
+}
+func (m *ReplicationEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationEvent proto.InternalMessageInfo
+
+func (m *ReplicationEvent) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *ReplicationEvent) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *ReplicationEvent) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *ReplicationEvent) GetBucket() string {
+	if m != nil {
+		return m.Bucket
+	}
+	return ""
+}
+
+func (m *ReplicationEvent) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ReplicationEvent) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *ReplicationEvent) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+type ReplicationStatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicationStatusRequest) Reset()         { *m = ReplicationStatusRequest{} }
+func (m *ReplicationStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*ReplicationStatusRequest) ProtoMessage()    {}
+func (*ReplicationStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{25}
+}
+
+func (m *ReplicationStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicationStatusRequest.Unmarshal(m, b)
+}
+func (m *ReplicationStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicationStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *ReplicationStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationStatusRequest.Merge(m, src)
+}
+func (m *ReplicationStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_ReplicationStatusRequest.Size(m)
+}
+func (m *ReplicationStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationStatusRequest proto.InternalMessageInfo
+
+// Replication state of the node
+type ReplicationStatus struct {
+	// One of primary, replica
+	Role string `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	// Address of the primary, for replicas
+	Primary string `protobuf:"bytes,2,opt,name=primary,proto3" json:"primary,omitempty"`
+	// True if the replica is streaming from the primary
+	Connected bool `protobuf:"varint,3,opt,name=connected,proto3" json:"connected,omitempty"`
+	// Last change committed on the primary, or applied on the replica
+	Seq uint64 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	// Last change committed on the primary, as known by the replica
+	PrimarySeq uint64 `protobuf:"varint,5,opt,name=primary_seq,json=primarySeq,proto3" json:"primary_seq,omitempty"`
+	// Delay in milliseconds between the commit on the primary and the apply on the replica
+	LagMs int64 `protobuf:"varint,6,opt,name=lag_ms,json=lagMs,proto3" json:"lag_ms,omitempty"`
+	// Last message from the primary as Unix timestamp
+	LastContact int64 `protobuf:"varint,7,opt,name=last_contact,json=lastContact,proto3" json:"last_contact,omitempty"`
+	// Number of replicas streaming from the primary
+	Replicas             int32    `protobuf:"varint,8,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicationStatus) Reset()         { *m = ReplicationStatus{} }
+func (m *ReplicationStatus) String() string { return proto.CompactTextString(m) }
+func (*ReplicationStatus) ProtoMessage()    {}
+func (*ReplicationStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{26}
+}
+
+func (m *ReplicationStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicationStatus.Unmarshal(m, b)
+}
+func (m *ReplicationStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicationStatus.Marshal(b, m, deterministic)
+}
+func (m *ReplicationStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicationStatus.Merge(m, src)
+}
+func (m *ReplicationStatus) XXX_Size() int {
+	return xxx_messageInfo_ReplicationStatus.Size(m)
+}
+func (m *ReplicationStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicationStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicationStatus proto.InternalMessageInfo
+
+func (m *ReplicationStatus) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+func (m *ReplicationStatus) GetPrimary() string {
+	if m != nil {
+		return m.Primary
+	}
+	return ""
+}
+
+func (m *ReplicationStatus) GetConnected() bool {
+	if m != nil {
+		return m.Connected
+	}
+	return false
+}
+
+func (m *ReplicationStatus) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *ReplicationStatus) GetPrimarySeq() uint64 {
+	if m != nil {
+		return m.PrimarySeq
+	}
+	return 0
+}
+
+func (m *ReplicationStatus) GetLagMs() int64 {
+	if m != nil {
+		return m.LagMs
+	}
+	return 0
+}
+
+func (m *ReplicationStatus) GetLastContact() int64 {
+	if m != nil {
+		return m.LastContact
+	}
+	return 0
+}
+
+func (m *ReplicationStatus) GetReplicas() int32 {
+	if m != nil {
+		return m.Replicas
+	}
+	return 0
+}
+
+// Node of the Raft cluster
+type ClusterNode struct {
+	// Unique ID of the node
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Raft address of the node, host:port
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// gRPC API address of the node, where the writes are forwarded when it is the leader
+	ApiAddress string `protobuf:"bytes,3,opt,name=api_address,json=apiAddress,proto3" json:"api_address,omitempty"`
+	// True if the node is the leader
+	Leader bool `protobuf:"varint,4,opt,name=leader,proto3" json:"leader,omitempty"`
+	// True if the node votes in the elections
+	Voter                bool     `protobuf:"varint,5,opt,name=voter,proto3" json:"voter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ClusterNode) Reset()         { *m = ClusterNode{} }
+func (m *ClusterNode) String() string { return proto.CompactTextString(m) }
+func (*ClusterNode) ProtoMessage()    {}
+func (*ClusterNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{27}
+}
+
+func (m *ClusterNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterNode.Unmarshal(m, b)
+}
+func (m *ClusterNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterNode.Marshal(b, m, deterministic)
+}
+func (m *ClusterNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterNode.Merge(m, src)
+}
+func (m *ClusterNode) XXX_Size() int {
+	return xxx_messageInfo_ClusterNode.Size(m)
+}
+func (m *ClusterNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterNode proto.InternalMessageInfo
+
+func (m *ClusterNode) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *ClusterNode) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *ClusterNode) GetApiAddress() string {
+	if m != nil {
+		return m.ApiAddress
+	}
+	return ""
+}
+
+func (m *ClusterNode) GetLeader() bool {
+	if m != nil {
+		return m.Leader
+	}
+	return false
+}
+
+func (m *ClusterNode) GetVoter() bool {
+	if m != nil {
+		return m.Voter
+	}
+	return false
+}
+
+type ClusterNodesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ClusterNodesRequest) Reset()         { *m = ClusterNodesRequest{} }
+func (m *ClusterNodesRequest) String() string { return proto.CompactTextString(m) }
+func (*ClusterNodesRequest) ProtoMessage()    {}
+func (*ClusterNodesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{28}
+}
+
+func (m *ClusterNodesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterNodesRequest.Unmarshal(m, b)
+}
+func (m *ClusterNodesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterNodesRequest.Marshal(b, m, deterministic)
+}
+func (m *ClusterNodesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterNodesRequest.Merge(m, src)
+}
+func (m *ClusterNodesRequest) XXX_Size() int {
+	return xxx_messageInfo_ClusterNodesRequest.Size(m)
+}
+func (m *ClusterNodesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterNodesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterNodesRequest proto.InternalMessageInfo
+
+type ClusterNodes struct {
+	Nodes                []*ClusterNode `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ClusterNodes) Reset()         { *m = ClusterNodes{} }
+func (m *ClusterNodes) String() string { return proto.CompactTextString(m) }
+func (*ClusterNodes) ProtoMessage()    {}
+func (*ClusterNodes) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{29}
+}
+
+func (m *ClusterNodes) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterNodes.Unmarshal(m, b)
+}
+func (m *ClusterNodes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterNodes.Marshal(b, m, deterministic)
+}
+func (m *ClusterNodes) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterNodes.Merge(m, src)
+}
+func (m *ClusterNodes) XXX_Size() int {
+	return xxx_messageInfo_ClusterNodes.Size(m)
+}
+func (m *ClusterNodes) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterNodes.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterNodes proto.InternalMessageInfo
+
+func (m *ClusterNodes) GetNodes() []*ClusterNode {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Record)(nil), "api.Record")
+	proto.RegisterType((*Lease)(nil), "api.Lease")
+	proto.RegisterType((*HistoryEntry)(nil), "api.HistoryEntry")
+	proto.RegisterType((*RecordHistory)(nil), "api.RecordHistory")
+	proto.RegisterType((*RestoreRequest)(nil), "api.RestoreRequest")
+	proto.RegisterType((*AuditEntry)(nil), "api.AuditEntry")
+	proto.RegisterType((*AuditQuery)(nil), "api.AuditQuery")
+	proto.RegisterType((*AuditLog)(nil), "api.AuditLog")
+	proto.RegisterType((*Prerequisite)(nil), "api.Prerequisite")
+	proto.RegisterType((*BatchOperation)(nil), "api.BatchOperation")
+	proto.RegisterType((*BatchRequest)(nil), "api.BatchRequest")
+	proto.RegisterType((*BatchResult)(nil), "api.BatchResult")
+	proto.RegisterType((*BatchResponse)(nil), "api.BatchResponse")
+	proto.RegisterType((*Token)(nil), "api.Token")
+	proto.RegisterType((*ListTokensRequest)(nil), "api.ListTokensRequest")
+	proto.RegisterType((*TokenList)(nil), "api.TokenList")
+	proto.RegisterType((*HealthCheck)(nil), "api.HealthCheck")
+	proto.RegisterType((*RecordValue)(nil), "api.RecordValue")
+	proto.RegisterType((*RecordSet)(nil), "api.RecordSet")
+	proto.RegisterType((*ListRecordsRequest)(nil), "api.ListRecordsRequest")
+	proto.RegisterType((*RecordList)(nil), "api.RecordList")
+	proto.RegisterType((*BackupRequest)(nil), "api.BackupRequest")
+	proto.RegisterType((*BackupChunk)(nil), "api.BackupChunk")
+	proto.RegisterType((*ReplicateRequest)(nil), "api.ReplicateRequest")
+	proto.RegisterType((*ReplicationEvent)(nil), "api.ReplicationEvent")
+	proto.RegisterType((*ReplicationStatusRequest)(nil), "api.ReplicationStatusRequest")
+	proto.RegisterType((*ReplicationStatus)(nil), "api.ReplicationStatus")
+	proto.RegisterType((*ClusterNode)(nil), "api.ClusterNode")
+	proto.RegisterType((*ClusterNodesRequest)(nil), "api.ClusterNodesRequest")
+	proto.RegisterType((*ClusterNodes)(nil), "api.ClusterNodes")
 }
 
 func init() { proto.RegisterFile("api/api.proto", fileDescriptor_1b40cafcd4234784) }
 
 var fileDescriptor_1b40cafcd4234784 = []byte{
-	// 271 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x90, 0x4f, 0x4b, 0xc3, 0x30,
-	0x18, 0xc6, 0x49, 0xbb, 0x76, 0xfa, 0xce, 0x7f, 0x04, 0x94, 0x50, 0x76, 0x28, 0x3d, 0x95, 0x1d,
-	0x16, 0xd4, 0x9b, 0xe0, 0x45, 0x7a, 0x1c, 0x22, 0x6d, 0x4f, 0xde, 0xe2, 0xfa, 0x32, 0x02, 0xb3,
-	0x09, 0x59, 0x28, 0xca, 0xd8, 0xc5, 0x8f, 0xa0, 0x37, 0xbf, 0x96, 0x5f, 0xc1, 0x0f, 0x22, 0x49,
-	0x2b, 0x7a, 0xdb, 0xed, 0xf9, 0xfd, 0x5a, 0x1e, 0xf2, 0xbc, 0x70, 0x2c, 0xb4, 0xe4, 0x42, 0xcb,
-	0xb9, 0x36, 0xca, 0x2a, 0x1a, 0x0a, 0x2d, 0x93, 0xe9, 0x4a, 0xa9, 0xd5, 0x1a, 0xb9, 0xff, 0xd4,
-	0xb6, 0xca, 0x0a, 0x2b, 0x55, 0xbb, 0xe9, 0x7f, 0xc9, 0xde, 0x09, 0xc4, 0x25, 0x2e, 0x95, 0x69,
-	0xe8, 0x09, 0x04, 0xb2, 0x61, 0x24, 0x25, 0xf9, 0x61, 0x19, 0xc8, 0x9e, 0x35, 0x0b, 0x06, 0xd6,
-	0xf4, 0x02, 0xe2, 0x46, 0x3d, 0x0b, 0xd9, 0xb2, 0xd0, 0xbb, 0x81, 0x28, 0x85, 0x91, 0x7d, 0xd5,
-	0xc8, 0x46, 0xde, 0xfa, 0x4c, 0x19, 0x8c, 0xf1, 0x45, 0x4b, 0x83, 0x1b, 0x16, 0xa5, 0x24, 0x8f,
-	0xca, 0x5f, 0xa4, 0x67, 0x10, 0xd6, 0xf5, 0x82, 0xc5, 0xde, 0xba, 0xe8, 0xcc, 0x43, 0x5d, 0xb2,
-	0x71, 0x4a, 0xf2, 0x83, 0xd2, 0xc5, 0xab, 0x4f, 0x02, 0x93, 0xa2, 0xb8, 0xaf, 0x2a, 0x34, 0x9d,
-	0x5c, 0x22, 0xbd, 0x05, 0xa8, 0x44, 0x87, 0xc3, 0x3b, 0x27, 0x73, 0xb7, 0xb0, 0x87, 0xe4, 0x3f,
-	0x64, 0xe7, 0x6f, 0x5f, 0xdf, 0x1f, 0xc1, 0x69, 0x06, 0xbc, 0xbb, 0xe4, 0xc6, 0xbb, 0x1b, 0x32,
-	0xa3, 0x0b, 0x38, 0x2a, 0x70, 0x8d, 0x76, 0x7f, 0x41, 0xe6, 0x0b, 0xa6, 0xb3, 0xe4, 0xaf, 0x80,
-	0x6f, 0xfb, 0x99, 0x3b, 0xbe, 0x75, 0xcb, 0x76, 0x77, 0xd1, 0xa3, 0x3b, 0xeb, 0x53, 0xec, 0xef,
-	0x77, 0xfd, 0x13, 0x00, 0x00, 0xff, 0xff, 0xdf, 0x4a, 0xe1, 0x99, 0x73, 0x01, 0x00, 0x00,
+	// 1963 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x58, 0xcd, 0x72, 0xdc, 0xc6,
+	0x11, 0x0e, 0x16, 0xbb, 0xcb, 0xdd, 0xc6, 0x72, 0xb9, 0x1c, 0x4a, 0x34, 0xbc, 0x91, 0x13, 0x7a,
+	0x92, 0x38, 0x34, 0x53, 0xf1, 0x26, 0x74, 0x95, 0x53, 0xe5, 0x94, 0x2a, 0xb6, 0x29, 0x95, 0x25,
+	0x17, 0x65, 0x3b, 0x43, 0xc5, 0x07, 0x5f, 0x36, 0x10, 0x30, 0xe2, 0x4e, 0x11, 0x04, 0x40, 0x60,
+	0x40, 0x99, 0xa5, 0xe8, 0x92, 0x43, 0x0e, 0xa9, 0xca, 0x29, 0xb9, 0xf8, 0x05, 0xf2, 0x42, 0x7e,
+	0x85, 0x1c, 0x52, 0xb9, 0xe6, 0x05, 0x52, 0xdd, 0x33, 0xf8, 0xe3, 0x52, 0x4a, 0xc9, 0xb7, 0xe9,
+	0xee, 0x99, 0x9e, 0xfe, 0xf9, 0xba, 0xa7, 0x01, 0xd8, 0x0c, 0x32, 0xb5, 0x08, 0x32, 0xf5, 0x5e,
+	0x96, 0xa7, 0x3a, 0x65, 0x6e, 0x90, 0xa9, 0xf9, 0x9d, 0xd3, 0x34, 0x3d, 0x8d, 0xe5, 0x82, 0x44,
+	0x49, 0x92, 0xea, 0x40, 0xab, 0x34, 0x29, 0xcc, 0x16, 0xfe, 0xad, 0x0b, 0x43, 0x21, 0xc3, 0x34,
+	0x8f, 0xd8, 0x14, 0x7a, 0x2a, 0xf2, 0x9d, 0x3d, 0x67, 0x7f, 0x2c, 0x7a, 0xca, 0xd0, 0x99, 0xdf,
+	0xb3, 0x74, 0xc6, 0x76, 0x61, 0x18, 0xa5, 0xe7, 0x81, 0x4a, 0x7c, 0x97, 0x78, 0x96, 0x62, 0x0c,
+	0xfa, 0xfa, 0x2a, 0x93, 0x7e, 0x9f, 0xb8, 0xb4, 0x66, 0x3e, 0x6c, 0xc8, 0x6f, 0x32, 0x95, 0xcb,
+	0xc2, 0x1f, 0xec, 0x39, 0xfb, 0xae, 0xa8, 0x48, 0x36, 0x03, 0xf7, 0xf1, 0xe3, 0x63, 0x7f, 0xb8,
+	0xe7, 0xec, 0x0f, 0x04, 0x2e, 0x91, 0xf3, 0xe5, 0x63, 0xe1, 0x6f, 0xec, 0x39, 0xfb, 0x23, 0x81,
+	0x4b, 0xbc, 0xe9, 0x99, 0x54, 0xa7, 0x2b, 0xed, 0x8f, 0x68, 0x9b, 0xa5, 0x90, 0x9f, 0xa5, 0xb1,
+	0x0a, 0xaf, 0xfc, 0xb1, 0xb1, 0xc0, 0x50, 0xc8, 0x0f, 0xb2, 0x4c, 0x26, 0x91, 0x0f, 0xa4, 0xc4,
+	0x52, 0xec, 0x1d, 0x18, 0x84, 0x2b, 0x19, 0x9e, 0xf9, 0xde, 0x9e, 0xb3, 0xef, 0x1d, 0xce, 0xde,
+	0xc3, 0xd0, 0x3c, 0x90, 0x41, 0xac, 0x57, 0x47, 0xc8, 0x17, 0x46, 0xcc, 0x6e, 0xc1, 0x20, 0x96,
+	0x41, 0x21, 0xfd, 0x09, 0x5d, 0x67, 0x08, 0xf6, 0x26, 0x8c, 0x68, 0xb1, 0x54, 0x91, 0xbf, 0x49,
+	0xf7, 0x6d, 0x10, 0xfd, 0x30, 0x62, 0x1c, 0x36, 0xcb, 0x42, 0x2e, 0xc3, 0x20, 0x8e, 0x65, 0xbe,
+	0x54, 0x99, 0x3f, 0xa5, 0x7b, 0xbd, 0xb2, 0x90, 0x47, 0xc4, 0x7b, 0x98, 0xe1, 0x71, 0xf5, 0x74,
+	0x79, 0x1e, 0xe8, 0x70, 0xe5, 0x6f, 0x99, 0xe3, 0xea, 0xe9, 0x23, 0x24, 0xd9, 0xcf, 0x60, 0x5a,
+	0x26, 0x2a, 0x4c, 0x23, 0xb9, 0xb4, 0x11, 0x9d, 0xd1, 0x86, 0x4d, 0xcb, 0xbd, 0x47, 0x4c, 0xfe,
+	0x08, 0x06, 0xc7, 0x64, 0xc9, 0xf5, 0xcc, 0xcc, 0x61, 0x14, 0x95, 0x39, 0xe5, 0x91, 0xf2, 0x33,
+	0x10, 0x35, 0xdd, 0x8e, 0xbc, 0xdb, 0x89, 0x3c, 0xff, 0xce, 0x81, 0xc9, 0x03, 0x55, 0xe8, 0x34,
+	0xbf, 0xba, 0x9f, 0xe8, 0xfc, 0x0a, 0xb7, 0x5e, 0xca, 0xbc, 0x40, 0x2d, 0x46, 0x77, 0x45, 0xb2,
+	0x77, 0x61, 0x96, 0xe5, 0xf2, 0x52, 0xa5, 0x65, 0xb1, 0xac, 0xb6, 0x18, 0x20, 0x6c, 0x55, 0xfc,
+	0xaf, 0xec, 0xd6, 0xb7, 0x00, 0xd2, 0x38, 0x5a, 0x5e, 0x06, 0x71, 0x49, 0x57, 0xba, 0xfb, 0x63,
+	0x31, 0x4e, 0xe3, 0xe8, 0x2b, 0x62, 0xa0, 0x38, 0x91, 0xcf, 0x2a, 0x71, 0xdf, 0x88, 0x13, 0xf9,
+	0xcc, 0x8a, 0x6f, 0xc1, 0x20, 0x08, 0x75, 0x9a, 0x13, 0x4a, 0xc6, 0xc2, 0x10, 0x98, 0xcf, 0x22,
+	0x2d, 0xf3, 0x50, 0x12, 0x4c, 0xc6, 0xc2, 0x52, 0x84, 0x34, 0x75, 0x2e, 0x09, 0x2a, 0xae, 0xa0,
+	0x35, 0x5f, 0xc1, 0xa6, 0xc1, 0xaf, 0x75, 0xad, 0x05, 0x53, 0xe7, 0x46, 0x98, 0xf6, 0x5a, 0x30,
+	0xfd, 0x05, 0x6c, 0xc8, 0x44, 0xe7, 0xca, 0x5a, 0xee, 0x1d, 0x6e, 0x1b, 0x88, 0xb4, 0xa2, 0x24,
+	0xaa, 0x1d, 0xfc, 0x02, 0xa6, 0x42, 0xa2, 0x40, 0x0a, 0x79, 0x51, 0xca, 0x42, 0xbf, 0xd6, 0x55,
+	0xad, 0x60, 0xbb, 0xdd, 0x60, 0xb7, 0x81, 0xd2, 0xef, 0x00, 0x85, 0xff, 0xd7, 0x01, 0xf8, 0xb8,
+	0x8c, 0x94, 0x36, 0x09, 0xab, 0xfc, 0x77, 0xac, 0x5e, 0x75, 0x2e, 0x9b, 0x08, 0xf6, 0xda, 0x11,
+	0xfc, 0x21, 0x8c, 0xc3, 0x58, 0xc9, 0x44, 0x23, 0x38, 0xcd, 0x7d, 0x23, 0xc3, 0x78, 0x98, 0xb5,
+	0xc2, 0xdb, 0xef, 0x84, 0x17, 0xcb, 0x28, 0x24, 0x50, 0x99, 0x6c, 0x58, 0x0a, 0xf9, 0x3a, 0xc8,
+	0x4f, 0xa5, 0xae, 0xd2, 0x61, 0x28, 0x74, 0x29, 0x2d, 0x75, 0x98, 0xda, 0x8c, 0x8c, 0x45, 0x45,
+	0xa2, 0x51, 0x32, 0xcf, 0xd3, 0x9c, 0xea, 0x77, 0x2c, 0x0c, 0x81, 0xe6, 0x23, 0x7a, 0x6c, 0xf1,
+	0xd2, 0x1a, 0x79, 0xab, 0xa0, 0x58, 0x51, 0xe1, 0x8e, 0x05, 0xad, 0xf9, 0x3f, 0x2a, 0xaf, 0x7f,
+	0x5f, 0xca, 0xfc, 0x0a, 0x95, 0x15, 0x2a, 0x09, 0x8d, 0xdb, 0xae, 0x30, 0x04, 0x72, 0xcb, 0x44,
+	0xab, 0x98, 0xfc, 0x76, 0x85, 0x21, 0x9a, 0x68, 0xb8, 0xd7, 0xf0, 0x64, 0x1d, 0xe8, 0x77, 0x1c,
+	0x68, 0x02, 0x31, 0xe8, 0x04, 0x02, 0xfb, 0x81, 0x3a, 0x57, 0xda, 0x76, 0x29, 0x43, 0xf0, 0x00,
+	0x46, 0x64, 0xd5, 0x71, 0x7a, 0xca, 0xde, 0x6d, 0x80, 0xe3, 0x10, 0x70, 0xb6, 0x08, 0x38, 0x4d,
+	0xae, 0x6a, 0xd8, 0xa0, 0xb2, 0xcb, 0x20, 0x56, 0x11, 0x19, 0x3a, 0x12, 0x86, 0x68, 0x22, 0xe4,
+	0xb6, 0x22, 0xc4, 0x57, 0x30, 0xf9, 0x32, 0x97, 0xb9, 0xbc, 0x28, 0x55, 0xa1, 0xb4, 0x7c, 0x2d,
+	0x80, 0x99, 0x76, 0xed, 0xd6, 0xed, 0xfa, 0x0e, 0x8c, 0xc3, 0x34, 0x89, 0x14, 0x25, 0xd4, 0xf8,
+	0xdd, 0x30, 0xf8, 0x7d, 0x98, 0x7e, 0x82, 0x10, 0xfb, 0x22, 0x93, 0xb6, 0x71, 0x4c, 0xa1, 0x97,
+	0x66, 0x55, 0x93, 0x49, 0x33, 0xf6, 0x13, 0x18, 0xe6, 0x54, 0x58, 0x74, 0x8b, 0x77, 0xe8, 0x91,
+	0x87, 0xa6, 0xd6, 0x84, 0x15, 0xf1, 0x3f, 0xc1, 0x84, 0xd4, 0x54, 0x15, 0xf1, 0x1b, 0xd8, 0xcc,
+	0x5a, 0x0e, 0x54, 0xd1, 0x31, 0x65, 0xd5, 0x76, 0x4d, 0x74, 0xf7, 0xb1, 0xf7, 0x01, 0xd2, 0xca,
+	0x94, 0xc2, 0xef, 0xd1, 0xa9, 0x1d, 0x3a, 0xd5, 0x35, 0x53, 0xb4, 0xb6, 0xf1, 0x04, 0x3c, 0x7b,
+	0x7b, 0x51, 0xc6, 0x1a, 0x63, 0xaa, 0x92, 0x48, 0x7e, 0x43, 0x4e, 0x0c, 0x84, 0x21, 0xac, 0x5f,
+	0xbd, 0x1b, 0xfc, 0x72, 0x5f, 0xea, 0x57, 0x93, 0x9e, 0x7e, 0x3b, 0x3d, 0xe7, 0xb0, 0x59, 0xdd,
+	0x97, 0xa5, 0x49, 0x41, 0x45, 0x1d, 0x64, 0x59, 0xac, 0xa4, 0xe9, 0xce, 0x23, 0x51, 0x91, 0x98,
+	0xb9, 0xa7, 0x81, 0x8a, 0x65, 0x44, 0xbe, 0x8c, 0x85, 0xa5, 0xd8, 0x01, 0x6c, 0xe4, 0x64, 0x6d,
+	0xd5, 0x71, 0x66, 0x8d, 0x93, 0xc6, 0x0d, 0x51, 0x6d, 0xe0, 0x05, 0x0c, 0x1e, 0xa7, 0x67, 0x32,
+	0x59, 0xeb, 0xff, 0x0c, 0xfa, 0x49, 0x70, 0x5e, 0xa7, 0x1f, 0xd7, 0x84, 0xe5, 0x30, 0xcd, 0xea,
+	0x1e, 0x6c, 0x29, 0xf4, 0x44, 0xa3, 0x92, 0xca, 0x13, 0x22, 0xd0, 0xf0, 0x30, 0x97, 0x81, 0x96,
+	0x51, 0xf5, 0x3e, 0x5b, 0x92, 0xef, 0xc0, 0xf6, 0xb1, 0x2a, 0x34, 0x5d, 0x5c, 0xd8, 0xb4, 0xf2,
+	0x05, 0x8c, 0x89, 0x81, 0x12, 0xc6, 0x61, 0x48, 0x4a, 0xaa, 0xe4, 0x02, 0x79, 0x40, 0x72, 0x61,
+	0x25, 0xfc, 0x5b, 0x07, 0xbc, 0xd6, 0x43, 0x5b, 0x03, 0xd6, 0x69, 0x01, 0x16, 0xdb, 0x41, 0x9a,
+	0x6b, 0xfb, 0x82, 0xd1, 0x9a, 0x78, 0x81, 0x5e, 0x59, 0x18, 0xd3, 0x9a, 0x3c, 0xd3, 0x81, 0x2e,
+	0x0b, 0x72, 0x61, 0x20, 0x2c, 0x85, 0x3e, 0x60, 0x07, 0x4c, 0x4b, 0x4d, 0x3e, 0x0c, 0x44, 0x45,
+	0x22, 0xf4, 0xf5, 0x2a, 0x97, 0xc5, 0x2a, 0x8d, 0x23, 0x5b, 0xc3, 0x0d, 0x83, 0xff, 0xd5, 0x01,
+	0xcf, 0xa4, 0x9b, 0x1e, 0x21, 0x5b, 0xa0, 0x65, 0x65, 0x9c, 0x21, 0x5a, 0x33, 0x48, 0xaf, 0x33,
+	0x83, 0xf8, 0xb0, 0xb1, 0x22, 0xc7, 0xae, 0xc8, 0xc8, 0x91, 0xa8, 0xc8, 0x9b, 0x31, 0x83, 0x0f,
+	0x60, 0x1c, 0x14, 0x7a, 0x69, 0x06, 0x11, 0x13, 0xec, 0x31, 0x72, 0x28, 0x30, 0xfc, 0x6f, 0x3d,
+	0x18, 0x1b, 0x63, 0x4e, 0xa4, 0x5e, 0x4b, 0x74, 0x53, 0xff, 0xbd, 0x1b, 0xeb, 0xdf, 0xbd, 0x79,
+	0xe4, 0xea, 0x77, 0x47, 0xae, 0x66, 0x6c, 0x1a, 0x74, 0xc6, 0xa6, 0x7a, 0x3c, 0x1a, 0xbe, 0x7a,
+	0x3c, 0xda, 0x87, 0xa1, 0x7d, 0xbf, 0x37, 0x5a, 0x90, 0x6d, 0x85, 0x50, 0x58, 0x79, 0x67, 0x64,
+	0x1a, 0x75, 0x47, 0xa6, 0xf5, 0x99, 0x67, 0x7c, 0xd3, 0xcc, 0xf3, 0x11, 0x30, 0x04, 0x99, 0x51,
+	0x5e, 0x7c, 0x8f, 0x87, 0x96, 0x7f, 0x00, 0x60, 0x4e, 0x13, 0x58, 0xf7, 0xb1, 0xde, 0x48, 0x97,
+	0x45, 0xeb, 0xb4, 0x65, 0xfc, 0x89, 0xa4, 0x6a, 0x23, 0x31, 0xdf, 0xc2, 0xe2, 0x0e, 0xcf, 0xca,
+	0xac, 0x02, 0xfd, 0xdb, 0xe0, 0x19, 0xc6, 0xd1, 0xaa, 0x4c, 0x08, 0xc2, 0x51, 0xa0, 0x03, 0xb2,
+	0x60, 0x22, 0x68, 0xcd, 0xdf, 0x81, 0x99, 0x90, 0x59, 0xac, 0xc2, 0x40, 0xd7, 0x43, 0x41, 0x55,
+	0x9c, 0x4e, 0x53, 0x9c, 0xfc, 0x9f, 0x4e, 0xb3, 0x51, 0xa5, 0xc9, 0xfd, 0x4b, 0x99, 0xe8, 0x1b,
+	0x6b, 0x62, 0x06, 0x6e, 0x21, 0x2f, 0xc8, 0x9f, 0xbe, 0xc0, 0x65, 0xfd, 0xe6, 0xbb, 0xcd, 0xcc,
+	0x83, 0xe1, 0x78, 0x52, 0x86, 0x67, 0xcd, 0x7b, 0x66, 0x28, 0x3c, 0x7d, 0x26, 0xab, 0x2c, 0xe3,
+	0xb2, 0xc1, 0xf6, 0x90, 0xac, 0x36, 0x04, 0x42, 0x25, 0x92, 0xb1, 0xc4, 0xea, 0x37, 0x53, 0x77,
+	0x45, 0xf2, 0x39, 0xf8, 0x2d, 0x3b, 0x4f, 0xa8, 0xd0, 0xaa, 0x78, 0xfc, 0xdb, 0x81, 0xed, 0x35,
+	0x21, 0xda, 0x97, 0xa7, 0x71, 0xed, 0x05, 0xae, 0x51, 0x7f, 0x96, 0xab, 0xf3, 0x20, 0xbf, 0xb2,
+	0x99, 0xa9, 0x48, 0xfb, 0x28, 0x25, 0x32, 0xc4, 0xbb, 0x4d, 0xfd, 0x34, 0x8c, 0xca, 0xfb, 0x7e,
+	0xe3, 0xfd, 0x8f, 0xc1, 0xb3, 0x47, 0x97, 0x28, 0x19, 0x90, 0x04, 0x2c, 0xeb, 0x44, 0x5e, 0xb0,
+	0xdb, 0x30, 0x8c, 0x83, 0xd3, 0xe5, 0x79, 0x41, 0x1e, 0xba, 0x62, 0x10, 0x07, 0xa7, 0x8f, 0x0a,
+	0xf6, 0x36, 0x4c, 0x4c, 0xd5, 0xa5, 0x89, 0x0e, 0x42, 0x6d, 0x27, 0x46, 0x8f, 0xea, 0xce, 0xb0,
+	0x70, 0x88, 0xce, 0x8d, 0x37, 0x85, 0xfd, 0xcc, 0xa8, 0x69, 0xfe, 0x17, 0x07, 0xbc, 0xa3, 0xb8,
+	0x2c, 0xb4, 0xcc, 0x3f, 0x4f, 0xa3, 0xf5, 0x01, 0x1c, 0xfb, 0x7e, 0x14, 0xe5, 0xb2, 0x28, 0x2a,
+	0x07, 0x2d, 0x89, 0x06, 0x07, 0x99, 0x5a, 0x56, 0x52, 0x53, 0xa0, 0x10, 0x64, 0xea, 0x63, 0xbb,
+	0x61, 0x17, 0x86, 0xb1, 0x0c, 0x22, 0x69, 0xda, 0xc4, 0x48, 0x58, 0x8a, 0x32, 0x95, 0x6a, 0x69,
+	0x26, 0xe1, 0x91, 0x30, 0x04, 0xbf, 0x0d, 0x3b, 0x2d, 0x3b, 0xea, 0x54, 0x7c, 0x00, 0x93, 0x36,
+	0x1b, 0x2b, 0x39, 0xc1, 0x85, 0xef, 0xb4, 0x0a, 0xb4, 0xb5, 0x43, 0x18, 0xf1, 0xe1, 0x7f, 0x00,
+	0xbc, 0x7b, 0xf7, 0x3e, 0x3f, 0x39, 0x91, 0xf9, 0xa5, 0x0a, 0x25, 0xbb, 0x0b, 0x70, 0x12, 0x5c,
+	0x4a, 0xfb, 0x01, 0xd8, 0x7e, 0x09, 0xe7, 0x6d, 0x82, 0xdf, 0xfe, 0xf3, 0x77, 0xff, 0xfa, 0x7b,
+	0x6f, 0x8b, 0xc3, 0xe2, 0xf2, 0xd7, 0x0b, 0x53, 0x30, 0x1f, 0x3a, 0x07, 0xec, 0x18, 0xc6, 0x9f,
+	0x4a, 0x7d, 0xd3, 0xe9, 0x6b, 0x55, 0xc6, 0x39, 0x29, 0xb8, 0xc3, 0xe6, 0x8d, 0x82, 0xc5, 0x73,
+	0x53, 0xc4, 0x2f, 0x16, 0xcf, 0x11, 0xfa, 0x2f, 0xd8, 0x31, 0x78, 0xad, 0xd2, 0x67, 0x6f, 0x90,
+	0x8a, 0xf5, 0x66, 0x30, 0xdf, 0x6a, 0xe9, 0x46, 0x31, 0xdf, 0x21, 0xe5, 0x9b, 0xcc, 0x6b, 0x94,
+	0x17, 0xec, 0x18, 0x26, 0xf7, 0x08, 0xd4, 0xff, 0xd7, 0x39, 0x6b, 0xdb, 0xc1, 0xab, 0x6c, 0xfb,
+	0x1d, 0x36, 0x95, 0x44, 0x3e, 0x33, 0xdf, 0x63, 0xe6, 0xc5, 0xa3, 0xf5, 0xbc, 0xb5, 0xe6, 0x6f,
+	0x92, 0xa6, 0x9d, 0xf9, 0x14, 0x35, 0x51, 0xe3, 0x5b, 0x3c, 0x57, 0xd1, 0x0b, 0x0c, 0xd5, 0x12,
+	0x66, 0x75, 0xa8, 0xaa, 0x2f, 0x95, 0x8e, 0x49, 0xac, 0x45, 0xd8, 0x0d, 0xfc, 0x80, 0xf4, 0xfd,
+	0x94, 0xf1, 0x97, 0x5b, 0xb6, 0x58, 0x59, 0x65, 0x7f, 0xc4, 0xef, 0x20, 0xfb, 0x75, 0x42, 0x0e,
+	0xef, 0x58, 0x85, 0xed, 0x2f, 0x96, 0xae, 0xe3, 0xbf, 0x24, 0xf5, 0x3f, 0xe7, 0xaf, 0x52, 0x9f,
+	0x9b, 0xf3, 0xe8, 0xc2, 0x67, 0x76, 0xda, 0xfa, 0x43, 0x16, 0x05, 0x5a, 0xb2, 0xed, 0xf6, 0xe0,
+	0x62, 0xb4, 0xb3, 0x36, 0xcb, 0x8c, 0x48, 0xfc, 0x16, 0x5d, 0x32, 0xe5, 0x63, 0xbc, 0xe4, 0x09,
+	0x8a, 0x50, 0xd7, 0x47, 0xe0, 0x7d, 0x2a, 0x75, 0x3d, 0x4e, 0xb7, 0xa6, 0x67, 0x9a, 0xf9, 0xe7,
+	0x9b, 0x0d, 0xe3, 0x38, 0x3d, 0xe5, 0xdb, 0xa4, 0xc4, 0x63, 0xa4, 0x24, 0x40, 0x2e, 0xfb, 0x2d,
+	0x78, 0x47, 0x34, 0xb2, 0x98, 0x11, 0xa9, 0x35, 0x84, 0xcc, 0x5b, 0xeb, 0xea, 0xfa, 0x0f, 0x9d,
+	0x03, 0x63, 0x81, 0x19, 0x7f, 0x1e, 0x00, 0x34, 0x43, 0x0e, 0xdb, 0xad, 0x91, 0xd6, 0x99, 0x7a,
+	0x2c, 0x88, 0xeb, 0xc1, 0x87, 0x33, 0xd2, 0x35, 0x61, 0x50, 0x2b, 0x2a, 0xd8, 0x5d, 0xf0, 0x0c,
+	0xcc, 0x5e, 0x6d, 0xc6, 0x2e, 0x1d, 0x9d, 0x1d, 0x4c, 0xeb, 0xa3, 0x84, 0x0c, 0x76, 0x08, 0x43,
+	0xf3, 0xc6, 0xb0, 0x2a, 0x76, 0xad, 0x17, 0x68, 0x3e, 0x6b, 0xf1, 0xe8, 0x11, 0xe2, 0x3f, 0xf8,
+	0x95, 0xc3, 0xee, 0xe2, 0xc4, 0x60, 0x1f, 0x1d, 0x76, 0xdb, 0x26, 0xb4, 0xfb, 0x08, 0xcd, 0xbb,
+	0xec, 0xea, 0xc9, 0xa1, 0xe3, 0x4f, 0xe1, 0x16, 0x21, 0xf1, 0x7a, 0x23, 0x7f, 0xeb, 0xfa, 0x91,
+	0x4e, 0xf7, 0x9f, 0xef, 0xde, 0x2c, 0xe6, 0x6f, 0x90, 0x67, 0xdb, 0x6c, 0xcb, 0x80, 0xa8, 0x16,
+	0xb3, 0x2f, 0xc0, 0xfb, 0x2c, 0x55, 0x89, 0xed, 0x42, 0x6c, 0xad, 0x27, 0xcd, 0xd7, 0x38, 0xfc,
+	0x0e, 0xe9, 0xda, 0xc5, 0x64, 0x6d, 0xa3, 0xba, 0xd0, 0xc8, 0x16, 0xd4, 0xbc, 0x98, 0x80, 0xc9,
+	0xb1, 0x0c, 0x2e, 0xe5, 0xeb, 0x68, 0xfc, 0x11, 0x69, 0xf4, 0x0f, 0x76, 0xd7, 0xd4, 0x99, 0xf8,
+	0x7f, 0x0d, 0x33, 0x4c, 0x6d, 0xa7, 0x99, 0xfa, 0xd7, 0xb5, 0xd4, 0x31, 0xd8, 0x5e, 0x93, 0x54,
+	0x25, 0xcf, 0xd6, 0xed, 0xfd, 0x64, 0xf0, 0x35, 0xfe, 0x7f, 0x7b, 0x32, 0xa4, 0x1f, 0x6d, 0xef,
+	0xff, 0x6f, 0x00, 0x74, 0x94, 0x70, 0x77, 0x9c, 0x13, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -162,7 +2191,28 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type DDNSServiceClient interface {
 	SaveRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error)
+	GetRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*RecordSet, error)
+	ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*RecordList, error)
 	DeleteRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error)
+	RenewLease(ctx context.Context, in *Lease, opts ...grpc.CallOption) (*Lease, error)
+	GetRecordHistory(ctx context.Context, in *Record, opts ...grpc.CallOption) (*RecordHistory, error)
+	RestoreRecord(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*Record, error)
+	BatchUpdate(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	GetAuditLog(ctx context.Context, in *AuditQuery, opts ...grpc.CallOption) (*AuditLog, error)
+	CreateToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*Token, error)
+	ListTokens(ctx context.Context, in *ListTokensRequest, opts ...grpc.CallOption) (*TokenList, error)
+	DeleteToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*Token, error)
+	// Backup stream a consistent snapshot of the database, served over HTTP
+	// as a file at /v1/backup
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (DDNSService_BackupClient, error)
+	// Replicate stream a snapshot of the database followed by the changes
+	Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (DDNSService_ReplicateClient, error)
+	GetReplicationStatus(ctx context.Context, in *ReplicationStatusRequest, opts ...grpc.CallOption) (*ReplicationStatus, error)
+	// JoinCluster add a node to the cluster, sent to the leader
+	JoinCluster(ctx context.Context, in *ClusterNode, opts ...grpc.CallOption) (*ClusterNode, error)
+	// LeaveCluster remove a node from the cluster
+	LeaveCluster(ctx context.Context, in *ClusterNode, opts ...grpc.CallOption) (*ClusterNode, error)
+	ListClusterNodes(ctx context.Context, in *ClusterNodesRequest, opts ...grpc.CallOption) (*ClusterNodes, error)
 }
 
 type dDNSServiceClient struct {
@@ -182,9 +2232,199 @@ func (c *dDNSServiceClient) SaveRecord(ctx context.Context, in *Record, opts ...
 	return out, nil
 }
 
-func (c *dDNSServiceClient) DeleteRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error) {
-	out := new(Record)
-	err := c.cc.Invoke(ctx, "/api.DDNSService/DeleteRecord", in, out, opts...)
+func (c *dDNSServiceClient) GetRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*RecordSet, error) {
+	out := new(RecordSet)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/GetRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) ListRecords(ctx context.Context, in *ListRecordsRequest, opts ...grpc.CallOption) (*RecordList, error) {
+	out := new(RecordList)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/ListRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) DeleteRecord(ctx context.Context, in *Record, opts ...grpc.CallOption) (*Record, error) {
+	out := new(Record)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/DeleteRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) RenewLease(ctx context.Context, in *Lease, opts ...grpc.CallOption) (*Lease, error) {
+	out := new(Lease)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/RenewLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) GetRecordHistory(ctx context.Context, in *Record, opts ...grpc.CallOption) (*RecordHistory, error) {
+	out := new(RecordHistory)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/GetRecordHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) RestoreRecord(ctx context.Context, in *RestoreRequest, opts ...grpc.CallOption) (*Record, error) {
+	out := new(Record)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/RestoreRecord", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) BatchUpdate(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/BatchUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) GetAuditLog(ctx context.Context, in *AuditQuery, opts ...grpc.CallOption) (*AuditLog, error) {
+	out := new(AuditLog)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/GetAuditLog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) CreateToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*Token, error) {
+	out := new(Token)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/CreateToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) ListTokens(ctx context.Context, in *ListTokensRequest, opts ...grpc.CallOption) (*TokenList, error) {
+	out := new(TokenList)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/ListTokens", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) DeleteToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*Token, error) {
+	out := new(Token)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/DeleteToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (DDNSService_BackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DDNSService_serviceDesc.Streams[0], "/api.DDNSService/Backup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dDNSServiceBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DDNSService_BackupClient interface {
+	Recv() (*BackupChunk, error)
+	grpc.ClientStream
+}
+
+type dDNSServiceBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *dDNSServiceBackupClient) Recv() (*BackupChunk, error) {
+	m := new(BackupChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dDNSServiceClient) Replicate(ctx context.Context, in *ReplicateRequest, opts ...grpc.CallOption) (DDNSService_ReplicateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DDNSService_serviceDesc.Streams[1], "/api.DDNSService/Replicate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dDNSServiceReplicateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DDNSService_ReplicateClient interface {
+	Recv() (*ReplicationEvent, error)
+	grpc.ClientStream
+}
+
+type dDNSServiceReplicateClient struct {
+	grpc.ClientStream
+}
+
+func (x *dDNSServiceReplicateClient) Recv() (*ReplicationEvent, error) {
+	m := new(ReplicationEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dDNSServiceClient) GetReplicationStatus(ctx context.Context, in *ReplicationStatusRequest, opts ...grpc.CallOption) (*ReplicationStatus, error) {
+	out := new(ReplicationStatus)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/GetReplicationStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) JoinCluster(ctx context.Context, in *ClusterNode, opts ...grpc.CallOption) (*ClusterNode, error) {
+	out := new(ClusterNode)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/JoinCluster", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) LeaveCluster(ctx context.Context, in *ClusterNode, opts ...grpc.CallOption) (*ClusterNode, error) {
+	out := new(ClusterNode)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/LeaveCluster", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dDNSServiceClient) ListClusterNodes(ctx context.Context, in *ClusterNodesRequest, opts ...grpc.CallOption) (*ClusterNodes, error) {
+	out := new(ClusterNodes)
+	err := c.cc.Invoke(ctx, "/api.DDNSService/ListClusterNodes", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +2434,28 @@ func (c *dDNSServiceClient) DeleteRecord(ctx context.Context, in *Record, opts .
 // DDNSServiceServer is the server API for DDNSService service.
 type DDNSServiceServer interface {
 	SaveRecord(context.Context, *Record) (*Record, error)
+	GetRecord(context.Context, *Record) (*RecordSet, error)
+	ListRecords(context.Context, *ListRecordsRequest) (*RecordList, error)
 	DeleteRecord(context.Context, *Record) (*Record, error)
+	RenewLease(context.Context, *Lease) (*Lease, error)
+	GetRecordHistory(context.Context, *Record) (*RecordHistory, error)
+	RestoreRecord(context.Context, *RestoreRequest) (*Record, error)
+	BatchUpdate(context.Context, *BatchRequest) (*BatchResponse, error)
+	GetAuditLog(context.Context, *AuditQuery) (*AuditLog, error)
+	CreateToken(context.Context, *Token) (*Token, error)
+	ListTokens(context.Context, *ListTokensRequest) (*TokenList, error)
+	DeleteToken(context.Context, *Token) (*Token, error)
+	// Backup stream a consistent snapshot of the database, served over HTTP
+	// as a file at /v1/backup
+	Backup(*BackupRequest, DDNSService_BackupServer) error
+	// Replicate stream a snapshot of the database followed by the changes
+	Replicate(*ReplicateRequest, DDNSService_ReplicateServer) error
+	GetReplicationStatus(context.Context, *ReplicationStatusRequest) (*ReplicationStatus, error)
+	// JoinCluster add a node to the cluster, sent to the leader
+	JoinCluster(context.Context, *ClusterNode) (*ClusterNode, error)
+	// LeaveCluster remove a node from the cluster
+	LeaveCluster(context.Context, *ClusterNode) (*ClusterNode, error)
+	ListClusterNodes(context.Context, *ClusterNodesRequest) (*ClusterNodes, error)
 }
 
 // UnimplementedDDNSServiceServer can be embedded to have forward compatible implementations.
@@ -204,9 +2465,57 @@ type UnimplementedDDNSServiceServer struct {
 func (*UnimplementedDDNSServiceServer) SaveRecord(ctx context.Context, req *Record) (*Record, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SaveRecord not implemented")
 }
+func (*UnimplementedDDNSServiceServer) GetRecord(ctx context.Context, req *Record) (*RecordSet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecord not implemented")
+}
+func (*UnimplementedDDNSServiceServer) ListRecords(ctx context.Context, req *ListRecordsRequest) (*RecordList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRecords not implemented")
+}
 func (*UnimplementedDDNSServiceServer) DeleteRecord(ctx context.Context, req *Record) (*Record, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecord not implemented")
 }
+func (*UnimplementedDDNSServiceServer) RenewLease(ctx context.Context, req *Lease) (*Lease, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewLease not implemented")
+}
+func (*UnimplementedDDNSServiceServer) GetRecordHistory(ctx context.Context, req *Record) (*RecordHistory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecordHistory not implemented")
+}
+func (*UnimplementedDDNSServiceServer) RestoreRecord(ctx context.Context, req *RestoreRequest) (*Record, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreRecord not implemented")
+}
+func (*UnimplementedDDNSServiceServer) BatchUpdate(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdate not implemented")
+}
+func (*UnimplementedDDNSServiceServer) GetAuditLog(ctx context.Context, req *AuditQuery) (*AuditLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAuditLog not implemented")
+}
+func (*UnimplementedDDNSServiceServer) CreateToken(ctx context.Context, req *Token) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateToken not implemented")
+}
+func (*UnimplementedDDNSServiceServer) ListTokens(ctx context.Context, req *ListTokensRequest) (*TokenList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTokens not implemented")
+}
+func (*UnimplementedDDNSServiceServer) DeleteToken(ctx context.Context, req *Token) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteToken not implemented")
+}
+func (*UnimplementedDDNSServiceServer) Backup(req *BackupRequest, srv DDNSService_BackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (*UnimplementedDDNSServiceServer) Replicate(req *ReplicateRequest, srv DDNSService_ReplicateServer) error {
+	return status.Errorf(codes.Unimplemented, "method Replicate not implemented")
+}
+func (*UnimplementedDDNSServiceServer) GetReplicationStatus(ctx context.Context, req *ReplicationStatusRequest) (*ReplicationStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReplicationStatus not implemented")
+}
+func (*UnimplementedDDNSServiceServer) JoinCluster(ctx context.Context, req *ClusterNode) (*ClusterNode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinCluster not implemented")
+}
+func (*UnimplementedDDNSServiceServer) LeaveCluster(ctx context.Context, req *ClusterNode) (*ClusterNode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveCluster not implemented")
+}
+func (*UnimplementedDDNSServiceServer) ListClusterNodes(ctx context.Context, req *ClusterNodesRequest) (*ClusterNodes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClusterNodes not implemented")
+}
 
 func RegisterDDNSServiceServer(s *grpc.Server, srv DDNSServiceServer) {
 	s.RegisterService(&_DDNSService_serviceDesc, srv)
@@ -230,6 +2539,42 @@ func _DDNSService_SaveRecord_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DDNSService_GetRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Record)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).GetRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/GetRecord",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).GetRecord(ctx, req.(*Record))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_ListRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).ListRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/ListRecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).ListRecords(ctx, req.(*ListRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _DDNSService_DeleteRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Record)
 	if err := dec(in); err != nil {
@@ -248,6 +2593,264 @@ func _DDNSService_DeleteRecord_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DDNSService_RenewLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Lease)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).RenewLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/RenewLease",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).RenewLease(ctx, req.(*Lease))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_GetRecordHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Record)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).GetRecordHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/GetRecordHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).GetRecordHistory(ctx, req.(*Record))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_RestoreRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).RestoreRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/RestoreRecord",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).RestoreRecord(ctx, req.(*RestoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_BatchUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).BatchUpdate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/BatchUpdate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).BatchUpdate(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_GetAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuditQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).GetAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/GetAuditLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).GetAuditLog(ctx, req.(*AuditQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_CreateToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Token)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).CreateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/CreateToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).CreateToken(ctx, req.(*Token))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_ListTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).ListTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/ListTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).ListTokens(ctx, req.(*ListTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_DeleteToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Token)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).DeleteToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/DeleteToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).DeleteToken(ctx, req.(*Token))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DDNSServiceServer).Backup(m, &dDNSServiceBackupServer{stream})
+}
+
+type DDNSService_BackupServer interface {
+	Send(*BackupChunk) error
+	grpc.ServerStream
+}
+
+type dDNSServiceBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *dDNSServiceBackupServer) Send(m *BackupChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DDNSService_Replicate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReplicateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DDNSServiceServer).Replicate(m, &dDNSServiceReplicateServer{stream})
+}
+
+type DDNSService_ReplicateServer interface {
+	Send(*ReplicationEvent) error
+	grpc.ServerStream
+}
+
+type dDNSServiceReplicateServer struct {
+	grpc.ServerStream
+}
+
+func (x *dDNSServiceReplicateServer) Send(m *ReplicationEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DDNSService_GetReplicationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).GetReplicationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/GetReplicationStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).GetReplicationStatus(ctx, req.(*ReplicationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_JoinCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterNode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).JoinCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/JoinCluster",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).JoinCluster(ctx, req.(*ClusterNode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_LeaveCluster_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterNode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).LeaveCluster(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/LeaveCluster",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).LeaveCluster(ctx, req.(*ClusterNode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DDNSService_ListClusterNodes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterNodesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DDNSServiceServer).ListClusterNodes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.DDNSService/ListClusterNodes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DDNSServiceServer).ListClusterNodes(ctx, req.(*ClusterNodesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _DDNSService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "api.DDNSService",
 	HandlerType: (*DDNSServiceServer)(nil),
@@ -256,11 +2859,78 @@ var _DDNSService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SaveRecord",
 			Handler:    _DDNSService_SaveRecord_Handler,
 		},
+		{
+			MethodName: "GetRecord",
+			Handler:    _DDNSService_GetRecord_Handler,
+		},
+		{
+			MethodName: "ListRecords",
+			Handler:    _DDNSService_ListRecords_Handler,
+		},
 		{
 			MethodName: "DeleteRecord",
 			Handler:    _DDNSService_DeleteRecord_Handler,
 		},
+		{
+			MethodName: "RenewLease",
+			Handler:    _DDNSService_RenewLease_Handler,
+		},
+		{
+			MethodName: "GetRecordHistory",
+			Handler:    _DDNSService_GetRecordHistory_Handler,
+		},
+		{
+			MethodName: "RestoreRecord",
+			Handler:    _DDNSService_RestoreRecord_Handler,
+		},
+		{
+			MethodName: "BatchUpdate",
+			Handler:    _DDNSService_BatchUpdate_Handler,
+		},
+		{
+			MethodName: "GetAuditLog",
+			Handler:    _DDNSService_GetAuditLog_Handler,
+		},
+		{
+			MethodName: "CreateToken",
+			Handler:    _DDNSService_CreateToken_Handler,
+		},
+		{
+			MethodName: "ListTokens",
+			Handler:    _DDNSService_ListTokens_Handler,
+		},
+		{
+			MethodName: "DeleteToken",
+			Handler:    _DDNSService_DeleteToken_Handler,
+		},
+		{
+			MethodName: "GetReplicationStatus",
+			Handler:    _DDNSService_GetReplicationStatus_Handler,
+		},
+		{
+			MethodName: "JoinCluster",
+			Handler:    _DDNSService_JoinCluster_Handler,
+		},
+		{
+			MethodName: "LeaveCluster",
+			Handler:    _DDNSService_LeaveCluster_Handler,
+		},
+		{
+			MethodName: "ListClusterNodes",
+			Handler:    _DDNSService_ListClusterNodes_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Backup",
+			Handler:       _DDNSService_Backup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Replicate",
+			Handler:       _DDNSService_Replicate_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/api.proto",
 }
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/muka/ddns/audit"
+	"github.com/muka/ddns/db"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// auditedMethods are the mutations written to the audit log, with their action
+var auditedMethods = map[string]string{
+	"/api.DDNSService/SaveRecord":    "save_record",
+	"/api.DDNSService/DeleteRecord":  "delete_record",
+	"/api.DDNSService/BatchUpdate":   "batch_update",
+	"/api.DDNSService/RestoreRecord": "restore_record",
+	"/api.DDNSService/RenewLease":    "renew_lease",
+	"/api.DDNSService/CreateToken":   "create_token",
+	"/api.DDNSService/DeleteToken":   "delete_token",
+	"/api.DDNSService/JoinCluster":   "join_cluster",
+	"/api.DDNSService/LeaveCluster":  "leave_cluster",
+}
+
+// auditedStreams are the streams written to the audit log, with their action
+var auditedStreams = map[string]string{
+	"/api.DDNSService/Backup": "backup",
+}
+
+type auditKey struct{}
+
+// setAuditActor set the authenticated client in the audit entry of a request
+func setAuditActor(ctx context.Context, actor string) {
+	if entry, ok := ctx.Value(auditKey{}).(*audit.Entry); ok {
+		entry.Actor = actor
+	}
+}
+
+// auditTarget describe the records changed by a request
+func auditTarget(req interface{}) string {
+	switch r := req.(type) {
+	case *Record:
+		return r.GetDomain() + " " + strings.ToUpper(r.GetType())
+	case *RestoreRequest:
+		return r.GetDomain() + " " + strings.ToUpper(r.GetType())
+	case *Lease:
+		return r.GetId()
+	case *ClusterNode:
+		return strings.TrimSpace(r.GetId() + " " + r.GetAddress())
+	case *Token:
+		if r.GetId() != "" {
+			return r.GetId()
+		}
+		return r.GetName()
+	case *BatchRequest:
+		targets := make([]string, 0, len(r.GetOperations()))
+		for _, op := range r.GetOperations() {
+			targets = append(targets, op.GetOp()+" "+auditTarget(op.GetRecord()))
+		}
+		return strings.Join(targets, ", ")
+	}
+	return ""
+}
+
+// outcome of a request for the audit log
+func outcome(err error) string {
+	switch status.Code(err) {
+	case codes.OK:
+		return audit.OutcomeSuccess
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return audit.OutcomeDenied
+	}
+	return audit.OutcomeFailure
+}
+
+// auditInterceptor write the mutations to the audit log, including the denied ones
+func auditInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	action, ok := auditedMethods[info.FullMethod]
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	entry := &audit.Entry{
+		Source: db.SourceAPI,
+		Action: action,
+		Target: auditTarget(req),
+	}
+	if ip, err := callerIP(ctx); err == nil {
+		entry.ClientIP = ip.String()
+	}
+
+	res, err := handler(context.WithValue(ctx, auditKey{}, entry), req)
+
+	entry.Outcome = outcome(err)
+	if err != nil {
+		entry.Error = status.Convert(err).Message()
+	} else if batch, ok := res.(*BatchResponse); ok && !batch.GetApplied() {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = "Batch not applied"
+	}
+
+	audit.Log(*entry)
+
+	return res, err
+}
+
+// auditServerStream carry the audit entry in the context of a stream
+type auditServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s auditServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// auditStreamInterceptor write the audited streams to the audit log when they end
+func auditStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	action, ok := auditedStreams[info.FullMethod]
+	if !ok {
+		return handler(srv, ss)
+	}
+
+	entry := &audit.Entry{
+		Source: db.SourceAPI,
+		Action: action,
+	}
+	if ip, err := callerIP(ss.Context()); err == nil {
+		entry.ClientIP = ip.String()
+	}
+
+	ctx := context.WithValue(ss.Context(), auditKey{}, entry)
+	err := handler(srv, auditServerStream{ServerStream: ss, ctx: ctx})
+
+	entry.Outcome = outcome(err)
+	if err != nil {
+		entry.Error = status.Convert(err).Message()
+	}
+
+	audit.Log(*entry)
+
+	return err
+}
+
+func (s *ddnsServer) GetAuditLog(ctx context.Context, msg *AuditQuery) (*AuditLog, error) {
+
+	filter := audit.Filter{
+		Actor:  msg.GetActor(),
+		Target: msg.GetTarget(),
+		Source: msg.GetSource(),
+		Limit:  int(msg.GetLimit()),
+	}
+	if msg.GetSince() > 0 {
+		filter.Since = time.Unix(msg.GetSince(), 0)
+	}
+	if msg.GetUntil() > 0 {
+		filter.Until = time.Unix(msg.GetUntil(), 0)
+	}
+
+	res, err := audit.Query(filter)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	list := &AuditLog{
+		Valid: res.Valid,
+		Error: res.Error,
+	}
+	for _, e := range res.Entries {
+		list.Entries = append(list.Entries, &AuditEntry{
+			Time:     e.Time,
+			Actor:    e.Actor,
+			ClientIp: e.ClientIP,
+			Source:   e.Source,
+			Action:   e.Action,
+			Target:   e.Target,
+			Outcome:  e.Outcome,
+			Error:    e.Error,
+			Prev:     e.Prev,
+			Hash:     e.Hash,
+		})
+	}
+
+	return list, nil
+}
@@ -0,0 +1,355 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/miekg/dns"
+	ddns "github.com/muka/ddns/dns"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Token scopes. Write can be limited to a domain suffix as `write:example.lan`
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// authorizationHeader is forwarded by the JSON gateway from the HTTP request
+const authorizationHeader = "authorization"
+
+// Identity of an authenticated client
+type Identity struct {
+	ID     string
+	Name   string
+	Scopes []string
+}
+
+type identityKey struct{}
+
+var (
+	authEnabled  bool
+	staticTokens = make(map[string]Identity)
+)
+
+//EnableAuth require a bearer token for every API call
+func EnableAuth(enabled bool) {
+	log.Debugf("API authentication enabled: %t", enabled)
+	authEnabled = enabled
+}
+
+//LoadTokens load static tokens from a file with lines in the format
+// `name:token:scope1,scope2`
+func LoadTokens(path string) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tokens := make(map[string]Identity)
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, ":", 3)
+		if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("Invalid token at %s:%d", path, line)
+		}
+
+		scopes, err := parseScopes(strings.Split(parts[2], ","))
+		if err != nil {
+			return fmt.Errorf("Invalid token at %s:%d: %s", path, line, err.Error())
+		}
+
+		tokens[hashToken(parts[1])] = Identity{
+			ID:     "static:" + parts[0],
+			Name:   parts[0],
+			Scopes: scopes,
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	log.Debugf("Loaded %d static tokens", len(tokens))
+	staticTokens = tokens
+
+	return nil
+}
+
+//IdentityFromContext return the authenticated client of a request
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func parseScopes(list []string) ([]string, error) {
+
+	scopes := make([]string, 0, len(list))
+	for _, scope := range list {
+
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+
+		switch {
+		case scope == ScopeRead, scope == ScopeWrite, scope == ScopeAdmin:
+		case strings.HasPrefix(scope, ScopeWrite+":"):
+			suffix := strings.TrimPrefix(scope, ScopeWrite+":")
+			suffix, err := ddns.CanonicalName(suffix)
+			if err != nil {
+				return nil, errors.New("Invalid domain in scope: " + scope)
+			}
+			scope = ScopeWrite + ":" + suffix
+		default:
+			return nil, errors.New("Scope not supported (Use one of read, write, write:<domain>, admin): " + scope)
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	if len(scopes) == 0 {
+		return nil, errors.New("Scopes are missing")
+	}
+
+	return scopes, nil
+}
+
+func (i Identity) isAdmin() bool {
+	for _, scope := range i.Scopes {
+		if scope == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (i Identity) canRead() bool {
+	for _, scope := range i.Scopes {
+		if scope == ScopeRead || scope == ScopeAdmin || strings.HasPrefix(scope, ScopeWrite) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i Identity) canWrite(domain string) bool {
+
+	domain, err := ddns.CanonicalName(domain)
+	if err != nil {
+		return false
+	}
+
+	for _, scope := range i.Scopes {
+
+		if scope == ScopeAdmin || scope == ScopeWrite {
+			return true
+		}
+
+		if !strings.HasPrefix(scope, ScopeWrite+":") {
+			continue
+		}
+
+		suffix := strings.TrimPrefix(scope, ScopeWrite+":")
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authenticate resolve the bearer token or the client certificate of a request
+func (s *ddnsServer) authenticate(ctx context.Context) (Identity, error) {
+
+	var values []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		values = md.Get(authorizationHeader)
+	}
+
+	if len(values) == 0 {
+		// fall back to the client certificate, if any
+		if identity, ok := authenticateCert(ctx); ok {
+			return identity, nil
+		}
+		return Identity{}, status.Error(codes.Unauthenticated, "Token is missing")
+	}
+
+	parts := strings.SplitN(values[0], " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") || strings.TrimSpace(parts[1]) == "" {
+		return Identity{}, status.Error(codes.Unauthenticated, "Bearer token is missing")
+	}
+
+	hash := hashToken(strings.TrimSpace(parts[1]))
+	identity, ok := staticTokens[hash]
+	if !ok {
+		token, err := s.db.GetToken(hash)
+		if err != nil {
+			return Identity{}, status.Error(codes.Unauthenticated, "Token is not valid")
+		}
+		identity = Identity{
+			ID:     token.ID,
+			Name:   token.Name,
+			Scopes: token.Scopes,
+		}
+	}
+
+	return onBehalfOf(ctx, identity)
+}
+
+// onBehalfOf return the client of a write forwarded by a node, verified by the
+// node, or the node itself if the write is its own. Only the nodes with the
+// admin scope can forward the clients
+func onBehalfOf(ctx context.Context, node Identity) (Identity, error) {
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return node, nil
+	}
+
+	names := md.Get(onBehalfOfHeader)
+	if len(names) == 0 {
+		return node, nil
+	}
+
+	if !node.isAdmin() {
+		return Identity{}, status.Error(codes.PermissionDenied, "Permission denied for "+node.Name+" to forward the writes of other clients")
+	}
+
+	var scopes []string
+	if values := md.Get(onBehalfScopesHeader); len(values) > 0 {
+		var err error
+		if scopes, err = parseScopes(strings.Split(values[0], ",")); err != nil {
+			return Identity{}, status.Error(codes.Unauthenticated, "Forwarded client not valid: "+err.Error())
+		}
+	}
+
+	return Identity{
+		ID:     node.ID + "/" + names[0],
+		Name:   names[0] + " (via " + node.Name + ")",
+		Scopes: scopes,
+	}, nil
+}
+
+// authorize check the identity has the scopes required by a method
+func (s *ddnsServer) authorize(identity Identity, method string, req interface{}) error {
+
+	denied := status.Error(codes.PermissionDenied, "Permission denied for "+identity.Name)
+
+	switch method {
+	case "/api.DDNSService/GetRecord", "/api.DDNSService/ListRecords", "/api.DDNSService/GetRecordHistory",
+		"/api.DDNSService/GetReplicationStatus", "/api.DDNSService/ListClusterNodes":
+		if !identity.canRead() {
+			return denied
+		}
+	case "/api.DDNSService/SaveRecord", "/api.DDNSService/DeleteRecord":
+		if !identity.canWrite(req.(*Record).GetDomain()) {
+			return denied
+		}
+	case "/api.DDNSService/RestoreRecord":
+		if !identity.canWrite(req.(*RestoreRequest).GetDomain()) {
+			return denied
+		}
+	case "/api.DDNSService/BatchUpdate":
+		batch := req.(*BatchRequest)
+		if len(batch.GetPrerequisites()) > 0 && !identity.canRead() {
+			return denied
+		}
+		for _, op := range batch.GetOperations() {
+			if !identity.canWrite(op.GetRecord().GetDomain()) {
+				return denied
+			}
+		}
+	case "/api.DDNSService/RenewLease":
+		domains, err := s.leaseDomains(req.(*Lease).GetId())
+		if err != nil {
+			// let the handler report the missing lease
+			return nil
+		}
+		for _, domain := range domains {
+			if !identity.canWrite(domain) {
+				return denied
+			}
+		}
+	default:
+		if !identity.isAdmin() {
+			return denied
+		}
+	}
+
+	return nil
+}
+
+// leaseDomains return the names of the records attached to a lease
+func (s *ddnsServer) leaseDomains(id string) ([]string, error) {
+
+	lease, err := s.db.GetLease(id)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, 0, len(lease.Keys))
+	for _, key := range lease.Keys {
+		record, err := s.db.GetRecord(key)
+		if err != nil {
+			continue
+		}
+		rr, err := dns.NewRR(record.RR)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, rr.Header().Name)
+	}
+
+	return domains, nil
+}
+
+// authInterceptor authenticate and authorize the gRPC calls
+func (s *ddnsServer) authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+	if !authEnabled {
+		return handler(ctx, req)
+	}
+
+	identity, err := s.authenticate(ctx)
+	if err != nil {
+		log.Debugf("Authentication failed for %s: %s", info.FullMethod, err.Error())
+		return nil, err
+	}
+
+	setAuditActor(ctx, identity.Name)
+
+	if err := s.authorize(identity, info.FullMethod, req); err != nil {
+		log.Debugf("Authorization failed for %s: %s", info.FullMethod, err.Error())
+		return nil, err
+	}
+
+	return handler(context.WithValue(ctx, identityKey{}, identity), req)
+}
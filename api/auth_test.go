@@ -0,0 +1,178 @@
+package api
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/muka/ddns/db"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func testServer() *ddnsServer {
+	return newDDNSServer(db.New(db.NewMemoryStore()))
+}
+
+// incoming return a context with the metadata of a call
+func incoming(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestParseScopes(t *testing.T) {
+
+	scopes, err := parseScopes([]string{" Read ", "write:Example.LAN", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 2 || scopes[0] != ScopeRead || scopes[1] != "write:example.lan." {
+		t.Errorf("Scopes are %v", scopes)
+	}
+
+	for _, list := range [][]string{{}, {""}, {"root"}, {"write:"}, {"write:foo..lan"}} {
+		if _, err := parseScopes(list); err == nil {
+			t.Errorf("Scopes %v should be refused", list)
+		}
+	}
+}
+
+func TestCanWrite(t *testing.T) {
+
+	identity := Identity{Name: "test", Scopes: []string{"write:example.lan."}}
+
+	allowed := []string{"example.lan", "foo.example.lan.", "FOO.Example.Lan"}
+	for _, domain := range allowed {
+		if !identity.canWrite(domain) {
+			t.Errorf("Write of %s should be allowed", domain)
+		}
+	}
+
+	denied := []string{"badexample.lan", "example.lan.evil", "lan", "foo..example.lan"}
+	for _, domain := range denied {
+		if identity.canWrite(domain) {
+			t.Errorf("Write of %s should be denied", domain)
+		}
+	}
+
+	if !(Identity{Scopes: []string{ScopeWrite}}).canWrite("any.domain") {
+		t.Errorf("Write scope should allow any domain")
+	}
+	if (Identity{Scopes: []string{ScopeRead}}).canWrite("example.lan") {
+		t.Errorf("Read scope should not allow writes")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+
+	s := testServer()
+	read := Identity{Name: "read", Scopes: []string{ScopeRead}}
+	lan := Identity{Name: "lan", Scopes: []string{"write:lan."}}
+	admin := Identity{Name: "admin", Scopes: []string{ScopeAdmin}}
+
+	tests := []struct {
+		identity Identity
+		method   string
+		req      interface{}
+		allowed  bool
+	}{
+		{read, "/api.DDNSService/ListRecords", nil, true},
+		{read, "/api.DDNSService/SaveRecord", &Record{Domain: "foo.lan"}, false},
+		{lan, "/api.DDNSService/GetRecord", nil, true},
+		{lan, "/api.DDNSService/SaveRecord", &Record{Domain: "foo.lan"}, true},
+		{lan, "/api.DDNSService/DeleteRecord", &Record{Domain: "foo.com"}, false},
+		{lan, "/api.DDNSService/RestoreRecord", &RestoreRequest{Domain: "foo.com"}, false},
+		{lan, "/api.DDNSService/BatchUpdate", &BatchRequest{Operations: []*BatchOperation{
+			{Op: batchUpsert, Record: &Record{Domain: "a.lan"}},
+			{Op: batchUpsert, Record: &Record{Domain: "b.com"}},
+		}}, false},
+		{lan, "/api.DDNSService/BatchUpdate", &BatchRequest{Operations: []*BatchOperation{
+			{Op: batchUpsert, Record: &Record{Domain: "a.lan"}},
+		}}, true},
+		{lan, "/api.DDNSService/CreateToken", &Token{}, false},
+		{lan, "/api.DDNSService/GetAuditLog", &AuditQuery{}, false},
+		{admin, "/api.DDNSService/CreateToken", &Token{}, true},
+		{admin, "/api.DDNSService/SaveRecord", &Record{Domain: "foo.com"}, true},
+	}
+
+	for _, test := range tests {
+		err := s.authorize(test.identity, test.method, test.req)
+		if test.allowed && err != nil {
+			t.Errorf("%s should be allowed %s: %v", test.identity.Name, test.method, err)
+		}
+		if !test.allowed && status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s should be denied %s: %v", test.identity.Name, test.method, err)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+
+	s := testServer()
+
+	previous := staticTokens
+	defer func() { staticTokens = previous }()
+	staticTokens = map[string]Identity{
+		hashToken("static-secret"): {ID: "static:ci", Name: "ci", Scopes: []string{ScopeRead}},
+	}
+
+	err := s.db.StoreToken(hashToken("stored-secret"), db.Token{ID: "t1", Name: "stored", Scopes: []string{ScopeAdmin}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, err := s.authenticate(incoming(authorizationHeader, "Bearer static-secret"))
+	if err != nil || identity.Name != "ci" {
+		t.Errorf("Static token identity is %+v: %v", identity, err)
+	}
+
+	identity, err = s.authenticate(incoming(authorizationHeader, "bearer stored-secret"))
+	if err != nil || identity.Name != "stored" || !identity.isAdmin() {
+		t.Errorf("Stored token identity is %+v: %v", identity, err)
+	}
+
+	for _, header := range []string{"", "Bearer ", "Basic c3RhdGljLXNlY3JldA==", "Bearer wrong", "static-secret"} {
+		ctx := context.Background()
+		if header != "" {
+			ctx = incoming(authorizationHeader, header)
+		}
+		if _, err := s.authenticate(ctx); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Authorization %q should be refused: %v", header, err)
+		}
+	}
+}
+
+func TestOnBehalfOf(t *testing.T) {
+
+	node := Identity{ID: "static:node", Name: "node", Scopes: []string{ScopeAdmin}}
+	ctx := incoming(onBehalfOfHeader, "bob", onBehalfScopesHeader, "write:bob.lan")
+
+	identity, err := onBehalfOf(ctx, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Name != "bob (via node)" || identity.isAdmin() || !identity.canWrite("bob.lan") || identity.canWrite("alice.lan") {
+		t.Errorf("Forwarded identity is %+v", identity)
+	}
+
+	// the forwarded client without scopes can not do anything
+	identity, err = onBehalfOf(incoming(onBehalfOfHeader, "anonymous"), node)
+	if err != nil || identity.canRead() {
+		t.Errorf("Forwarded identity without scopes is %+v: %v", identity, err)
+	}
+
+	// only the admin tokens can forward the clients
+	user := Identity{Name: "user", Scopes: []string{"write:bob.lan"}}
+	if _, err := onBehalfOf(ctx, user); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Forward by a client should be denied: %v", err)
+	}
+
+	if _, err := onBehalfOf(incoming(onBehalfOfHeader, "bob", onBehalfScopesHeader, "root"), node); err == nil {
+		t.Errorf("Forwarded scopes not valid should be refused")
+	}
+
+	identity, err = onBehalfOf(incoming(), user)
+	if err != nil || identity.Name != "user" {
+		t.Errorf("Identity without forward is %+v: %v", identity, err)
+	}
+}
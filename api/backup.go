@@ -0,0 +1,142 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/muka/ddns/db"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// backupChunkSize is the max size of the chunks of a backup stream
+const backupChunkSize = 64 * 1024
+
+// backupWriter send the snapshot to a backup stream in chunks
+type backupWriter struct {
+	stream DDNSService_BackupServer
+}
+
+func (w backupWriter) Write(p []byte) (int, error) {
+	sent := 0
+	for sent < len(p) {
+		end := sent + backupChunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+		if err := w.stream.Send(&BackupChunk{Data: p[sent:end]}); err != nil {
+			return sent, err
+		}
+		sent = end
+	}
+	return sent, nil
+}
+
+func (s *ddnsServer) Backup(msg *BackupRequest, stream DDNSService_BackupServer) error {
+
+	n, err := s.db.Snapshot(backupWriter{stream: stream})
+	if err == db.ErrSnapshotUnsupported {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Sent backup of %d bytes", n)
+	return nil
+}
+
+// errorStreamInterceptor convert the errors of the gRPC streams to status errors
+func errorStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return toStatus(handler(srv, ss))
+}
+
+// authStreamInterceptor authenticate and authorize the gRPC streams
+func (s *ddnsServer) authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	if !authEnabled {
+		return handler(srv, ss)
+	}
+
+	identity, err := s.authenticate(ss.Context())
+	if err != nil {
+		log.Debugf("Authentication failed for %s: %s", info.FullMethod, err.Error())
+		return err
+	}
+
+	setAuditActor(ss.Context(), identity.Name)
+
+	if err := s.authorize(identity, info.FullMethod, nil); err != nil {
+		log.Debugf("Authorization failed for %s: %s", info.FullMethod, err.Error())
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// backupHandler serve the backup stream as a file, the request is
+// authenticated by the gRPC service like the JSON gateway ones
+func backupHandler(client DDNSServiceClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+
+		if req.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		md := gatewayMetadata(req.Context(), req)
+		if auth := req.Header.Get("Authorization"); auth != "" {
+			md = metadata.Join(md, metadata.Pairs(authorizationHeader, auth))
+		}
+		// append the client address like the JSON gateway, for the audit log
+		if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+			forwarded := host
+			if header := req.Header.Get("X-Forwarded-For"); header != "" {
+				forwarded = header + ", " + host
+			}
+			md = metadata.Join(md, metadata.Pairs(forwardedHeader, forwarded))
+		}
+
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+
+		var chunk *BackupChunk
+		stream, err := client.Backup(metadata.NewOutgoingContext(ctx, md), &BackupRequest{})
+		if err == nil {
+			// the first chunk report the errors before the response is sent
+			chunk, err = stream.Recv()
+		}
+		if err != nil {
+			st := status.Convert(err)
+			http.Error(w, st.Message(), runtime.HTTPStatusFromCode(st.Code()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"ddns-%s.db\"", time.Now().Format("20060102150405")))
+
+		for {
+			if _, err := w.Write(chunk.GetData()); err != nil {
+				log.Errorf("Backup download failed: %s", err.Error())
+				return
+			}
+			chunk, err = stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				// the response is truncated, the client can not read a valid database
+				log.Errorf("Backup stream failed: %s", err.Error())
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/miekg/dns"
+	"github.com/muka/ddns/db"
+	ddns "github.com/muka/ddns/dns"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/status"
+)
+
+// Batch operations
+const (
+	batchUpsert = "upsert"
+	batchDelete = "delete"
+)
+
+// Batch prerequisite conditions
+const (
+	conditionExists    = "exists"
+	conditionNotExists = "not_exists"
+)
+
+// errBatchFailed roll back the batch transaction
+var errBatchFailed = errors.New("Batch failed")
+
+func (s *ddnsServer) BatchUpdate(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	log.Debugf("Batch request with %d operations", len(req.GetOperations()))
+
+	if len(req.GetOperations()) == 0 {
+		return nil, invalidArgument("operations", "Operations are missing")
+	}
+
+	res := &BatchResponse{}
+	for i, op := range req.GetOperations() {
+		res.Results = append(res.Results, &BatchResult{
+			Index:  int32(i),
+			Op:     op.GetOp(),
+			Record: op.GetRecord(),
+		})
+	}
+
+	for i, op := range req.GetOperations() {
+		if op.GetOp() != batchUpsert || op.GetRecord() == nil {
+			continue
+		}
+		if err := setCallerIP(ctx, op.GetRecord()); err != nil {
+			res.Results[i].Error = status.Convert(err).Message()
+			return res, nil
+		}
+	}
+
+	err := s.db.Update(origin(ctx), func(tx *db.Tx) error {
+
+		for _, prerequisite := range req.GetPrerequisites() {
+			if err := checkPrerequisite(tx, prerequisite); err != nil {
+				res.Failed = append(res.Failed, err.Error())
+			}
+		}
+		if len(res.Failed) > 0 {
+			return errBatchFailed
+		}
+
+		for i, op := range req.GetOperations() {
+			if err := applyOperation(tx, op); err != nil {
+				res.Results[i].Error = status.Convert(err).Message()
+				return errBatchFailed
+			}
+		}
+
+		return nil
+	})
+
+	if err == errBatchFailed {
+		log.Debugf("Batch not applied")
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res.Applied = true
+	return res, nil
+}
+
+// applyOperation run a batch operation in the transaction
+func applyOperation(tx *db.Tx, op *BatchOperation) error {
+
+	if op.GetRecord() == nil {
+		return invalidArgument("record", "Record is missing")
+	}
+
+	switch op.GetOp() {
+	case batchUpsert:
+		return saveRecord(tx, op.GetRecord())
+	case batchDelete:
+		return deleteRecord(tx, op.GetRecord())
+	}
+
+	return invalidArgument("op", "Operation not supported (Use one of upsert, delete): "+op.GetOp())
+}
+
+// checkPrerequisite verify the condition on the stored records
+func checkPrerequisite(tx *db.Tx, prerequisite *Prerequisite) error {
+
+	name := prerequisite.GetDomain() + " " + prerequisite.GetType()
+	if prerequisite.GetIp() != "" {
+		name += " " + prerequisite.GetIp()
+	}
+
+	rtype, ok := dns.StringToType[strings.ToUpper(prerequisite.GetType())]
+	if !ok || prerequisite.GetDomain() == "" {
+		return fmt.Errorf("%s: domain and type are required", name)
+	}
+
+	key, err := ddns.GetKey(prerequisite.GetDomain(), rtype)
+	if err != nil {
+		return fmt.Errorf("%s: %s", name, err.Error())
+	}
+
+	record, err := tx.GetRecord(key)
+	if err != nil && !db.IsNotFound(err) {
+		return fmt.Errorf("%s: %s", name, err.Error())
+	}
+
+	exists := err == nil
+	if exists && prerequisite.GetIp() != "" {
+		exists, err = hasValue(record, &Record{
+			Domain: prerequisite.GetDomain(),
+			Type:   prerequisite.GetType(),
+			Ip:     prerequisite.GetIp(),
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	switch prerequisite.GetCondition() {
+	case "", conditionExists:
+		if !exists {
+			return fmt.Errorf("%s does not exist", name)
+		}
+	case conditionNotExists:
+		if exists {
+			return fmt.Errorf("%s exists", name)
+		}
+	default:
+		return fmt.Errorf("%s: condition not supported (Use one of exists, not_exists)", name)
+	}
+
+	return nil
+}
+
+// hasValue check if a record set includes a value
+func hasValue(record db.Record, msg *Record) (bool, error) {
+
+	rr := getRecord(msg)
+	if rr == nil {
+		return false, errors.New("Record type not supported (Use one of A, AAAA, MX, CNAME)")
+	}
+
+	for _, value := range record.GetValues() {
+		current, err := dns.NewRR(value.RR)
+		if err != nil {
+			return false, err
+		}
+		if ddns.SameRecord(current, rr) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
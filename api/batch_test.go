@@ -0,0 +1,61 @@
+package api
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+func TestBatchUpdateAllOrNothing(t *testing.T) {
+
+	s := testServer()
+	ctx := context.Background()
+
+	// the failed operation discard the previous ones
+	res, err := s.BatchUpdate(ctx, &BatchRequest{Operations: []*BatchOperation{
+		{Op: batchUpsert, Record: &Record{Domain: "a.local.lan", Type: "A", Ip: "10.0.0.1"}},
+		{Op: batchUpsert, Record: &Record{Domain: "b.local.lan", Type: "A", Ip: "not an ip"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GetApplied() || res.GetResults()[1].GetError() == "" {
+		t.Errorf("Batch with a failed operation applied: %+v", res)
+	}
+	if _, err := s.GetRecord(ctx, &Record{Domain: "a.local.lan", Type: "A"}); err == nil {
+		t.Errorf("Operation of a failed batch stored")
+	}
+
+	// the failed prerequisites discard all the operations
+	res, err = s.BatchUpdate(ctx, &BatchRequest{
+		Prerequisites: []*Prerequisite{{Domain: "missing.local.lan", Type: "A"}},
+		Operations: []*BatchOperation{
+			{Op: batchUpsert, Record: &Record{Domain: "a.local.lan", Type: "A", Ip: "10.0.0.1"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GetApplied() || len(res.GetFailed()) != 1 {
+		t.Errorf("Batch with a failed prerequisite applied: %+v", res)
+	}
+	if _, err := s.GetRecord(ctx, &Record{Domain: "a.local.lan", Type: "A"}); err == nil {
+		t.Errorf("Operation of a batch with failed prerequisites stored")
+	}
+
+	res, err = s.BatchUpdate(ctx, &BatchRequest{
+		Prerequisites: []*Prerequisite{{Domain: "a.local.lan", Type: "A", Condition: "not_exists"}},
+		Operations: []*BatchOperation{
+			{Op: batchUpsert, Record: &Record{Domain: "a.local.lan", Type: "A", Ip: "10.0.0.1"}},
+			{Op: batchUpsert, Record: &Record{Domain: "b.local.lan", Type: "A", Ip: "10.0.0.2"}},
+		},
+	})
+	if err != nil || !res.GetApplied() {
+		t.Fatalf("Batch not applied: %+v, %v", res, err)
+	}
+	for _, domain := range []string{"a.local.lan", "b.local.lan"} {
+		if _, err := s.GetRecord(ctx, &Record{Domain: domain, Type: "A"}); err != nil {
+			t.Errorf("Record %s of the batch not stored: %v", domain, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+
+	ddns "github.com/muka/ddns/dns"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// forwardedHeader is set by proxies and by the JSON gateway with the client address
+const forwardedHeader = "x-forwarded-for"
+
+// trustedProxies are allowed to set the client address in X-Forwarded-For,
+// replaced on configuration reload
+var trustedProxiesMu sync.RWMutex
+var trustedProxies = ddns.ACL{
+	{IP: net.IPv4(127, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv6loopback, Mask: net.CIDRMask(128, 128)},
+}
+
+//ParseTrustedProxies parse a comma separated list of proxy networks, see
+// ddns.ParseACL. An empty list trusts no proxy, any is refused
+func ParseTrustedProxies(list string) (ddns.ACL, error) {
+
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return ddns.ACL{}, nil
+	}
+	if list == "any" {
+		return nil, errors.New("Trusting any proxy allows every client to forge its address, list the proxy networks")
+	}
+
+	return ddns.ParseACL(list)
+}
+
+//SetTrustedProxies set the networks of the proxies allowed to forward the
+// client address, a nil ACL trusts no proxy
+func SetTrustedProxies(acl ddns.ACL) {
+	if acl == nil {
+		acl = ddns.ACL{}
+	}
+	log.Debugf("Trusted proxies %s", acl)
+	trustedProxiesMu.Lock()
+	defer trustedProxiesMu.Unlock()
+	trustedProxies = acl
+}
+
+// callerIP return the address of the caller of a gRPC request
+func callerIP(ctx context.Context) (net.IP, error) {
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, errors.New("Cannot detect caller IP")
+	}
+
+	var hops []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		hops = forwardedHops(md.Get(forwardedHeader))
+	}
+
+	remote := ddns.ClientIP(p.Addr)
+	if isInProcess(p.Addr) && len(hops) > 0 {
+		// the JSON gateway append the address of the HTTP client
+		remote = net.ParseIP(hops[len(hops)-1])
+		hops = hops[:len(hops)-1]
+	}
+
+	ip := resolveForwarded(remote, hops)
+	if ip == nil {
+		return nil, errors.New("Cannot detect caller IP")
+	}
+
+	return ip, nil
+}
+
+// httpCallerIP return the address of the caller of an HTTP request
+func httpCallerIP(req *http.Request) net.IP {
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	return resolveForwarded(net.ParseIP(host), forwardedHops(req.Header[http.CanonicalHeaderKey(forwardedHeader)]))
+}
+
+//ForwardedIP return the client address forwarded by the trusted proxies in the
+// forwarded values, or remote if it is not a trusted proxy
+func ForwardedIP(remote net.IP, forwarded []string) net.IP {
+	return resolveForwarded(remote, forwardedHops(forwarded))
+}
+
+// forwardedHops split the forwarded header values in addresses
+func forwardedHops(forwarded []string) []string {
+	hops := make([]string, 0)
+	for _, value := range forwarded {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// resolveForwarded walk the forwarded addresses from the closest hop, stopping
+// at the first address not belonging to a trusted proxy
+func resolveForwarded(remote net.IP, hops []string) net.IP {
+
+	trustedProxiesMu.RLock()
+	proxies := trustedProxies
+	trustedProxiesMu.RUnlock()
+
+	ip := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+
+		if ip == nil || !proxies.Allowed(ip) {
+			break
+		}
+
+		hop := net.ParseIP(hops[i])
+		if hop == nil {
+			log.Debugf("Invalid forwarded address %s", hops[i])
+			break
+		}
+
+		ip = hop
+	}
+
+	return ip
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/muka/ddns/db"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// clusterJoinRetry is the delay between the attempts to join the cluster
+const clusterJoinRetry = 5 * time.Second
+
+// clusterNode is set when running in a Raft cluster
+var clusterNode *Cluster
+
+// Cluster serve the membership RPCs of a cluster node and forward the
+// writes received by a follower to the leader
+type Cluster struct {
+	cluster *db.Cluster
+	config  db.ClusterConfig
+	token   string
+	opts    []grpc.DialOption
+	stop    chan struct{}
+
+	mu      sync.Mutex
+	address string
+	conn    *grpc.ClientConn
+	client  DDNSServiceClient
+}
+
+//RunCluster serve the cluster API of a node. If join is set, the node asks to
+// join the cluster to the node at that gRPC address. The token authenticate
+// the join request and the forwarded writes
+func RunCluster(c *db.Cluster, join string, token string, opts ...grpc.DialOption) *Cluster {
+
+	cl := &Cluster{
+		cluster: c,
+		config:  c.Config(),
+		token:   token,
+		opts:    opts,
+		stop:    make(chan struct{}),
+	}
+	clusterNode = cl
+
+	if join != "" {
+		go cl.join(join)
+	}
+
+	return cl
+}
+
+//Stop close the connection to the leader
+func (cl *Cluster) Stop() {
+	close(cl.stop)
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if cl.conn != nil {
+		cl.conn.Close()
+		cl.conn = nil
+	}
+}
+
+// join send the join request until accepted
+func (cl *Cluster) join(address string) {
+	for {
+		err := cl.requestJoin(address)
+		if err == nil {
+			log.Infof("Joined the cluster of %s", address)
+			return
+		}
+
+		log.Warnf("Failed to join the cluster of %s: %s", address, err.Error())
+
+		select {
+		case <-cl.stop:
+			return
+		case <-time.After(clusterJoinRetry):
+		}
+	}
+}
+
+func (cl *Cluster) requestJoin(address string) error {
+
+	conn, err := grpc.Dial(address, cl.opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if cl.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, authorizationHeader, "Bearer "+cl.token)
+	}
+
+	_, err = NewDDNSServiceClient(conn).JoinCluster(ctx, &ClusterNode{
+		Id:         cl.config.ID,
+		Address:    cl.config.Address,
+		ApiAddress: cl.config.APIAddress,
+	})
+	return err
+}
+
+// leaderClient return a client of the leader API
+func (cl *Cluster) leaderClient() (DDNSServiceClient, string, error) {
+
+	leader, ok := cl.cluster.Leader()
+	if !ok {
+		return nil, "", status.Error(codes.Unavailable, "The cluster has no leader")
+	}
+	if leader.APIAddress == "" {
+		return nil, "", status.Error(codes.Unavailable, "The API address of the leader "+leader.ID+" is unknown")
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	if cl.conn != nil && cl.address == leader.APIAddress {
+		return cl.client, cl.address, nil
+	}
+
+	if cl.conn != nil {
+		cl.conn.Close()
+		cl.conn = nil
+	}
+
+	conn, err := grpc.Dial(leader.APIAddress, cl.opts...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cl.conn = conn
+	cl.address = leader.APIAddress
+	cl.client = NewDDNSServiceClient(conn)
+
+	return cl.client, cl.address, nil
+}
+
+// clusterWrites are the membership changes sent to the leader by the followers
+var clusterWrites = map[string]func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error){
+	"/api.DDNSService/JoinCluster": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.JoinCluster(ctx, req.(*ClusterNode))
+	},
+	"/api.DDNSService/LeaveCluster": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.LeaveCluster(ctx, req.(*ClusterNode))
+	},
+}
+
+func (cl *Cluster) forwardWrite(ctx context.Context, method string, req interface{}) (interface{}, bool, error) {
+
+	write, ok := replicaWrites[method]
+	if !ok {
+		write, ok = clusterWrites[method]
+	}
+	if !ok || cl.cluster.IsLeader() {
+		return nil, false, nil
+	}
+
+	// the leader changed since the write was forwarded, the client retries
+	if isForwarded(ctx) {
+		return nil, true, status.Error(codes.Unavailable, db.ErrNotLeader.Error())
+	}
+
+	client, address, err := cl.leaderClient()
+	if err != nil {
+		return nil, true, err
+	}
+
+	log.Debugf("Forwarding %s to the leader %s", method, address)
+	res, err := write(forwardContext(ctx, cl.token), client, req)
+	return res, true, err
+}
+
+func (s *ddnsServer) JoinCluster(ctx context.Context, msg *ClusterNode) (*ClusterNode, error) {
+
+	if clusterNode == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Not running in a cluster")
+	}
+	if msg.GetId() == "" {
+		return nil, invalidArgument("id", "Node ID is missing")
+	}
+	if msg.GetAddress() == "" {
+		return nil, invalidArgument("address", "Node address is missing")
+	}
+
+	err := clusterNode.cluster.Join(db.ClusterNode{
+		ID:         msg.GetId(),
+		Address:    msg.GetAddress(),
+		APIAddress: msg.GetApiAddress(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Voter = true
+	return msg, nil
+}
+
+func (s *ddnsServer) LeaveCluster(ctx context.Context, msg *ClusterNode) (*ClusterNode, error) {
+
+	if clusterNode == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Not running in a cluster")
+	}
+	if msg.GetId() == "" {
+		return nil, invalidArgument("id", "Node ID is missing")
+	}
+
+	if err := clusterNode.cluster.Leave(msg.GetId()); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+func (s *ddnsServer) ListClusterNodes(ctx context.Context, msg *ClusterNodesRequest) (*ClusterNodes, error) {
+
+	if clusterNode == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Not running in a cluster")
+	}
+
+	nodes, err := clusterNode.cluster.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	list := &ClusterNodes{Nodes: make([]*ClusterNode, 0, len(nodes))}
+	for _, node := range nodes {
+		list.Nodes = append(list.Nodes, &ClusterNode{
+			Id:         node.ID,
+			Address:    node.Address,
+			ApiAddress: node.APIAddress,
+			Leader:     node.Leader,
+			Voter:      node.Voter,
+		})
+	}
+
+	return list, nil
+}
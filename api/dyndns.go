@@ -0,0 +1,375 @@
+package api
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/miekg/dns"
+	"github.com/muka/ddns/audit"
+	"github.com/muka/ddns/db"
+	ddns "github.com/muka/ddns/dns"
+	log "github.com/sirupsen/logrus"
+)
+
+// DynDNS2 protocol return codes
+const (
+	dyndnsGood    = "good"
+	dyndnsNoChg   = "nochg"
+	dyndnsBadAuth = "badauth"
+	dyndnsNoHost  = "nohost"
+	dyndnsNotFQDN = "notfqdn"
+	dyndnsNumHost = "numhost"
+	dyndnsAbuse   = "abuse"
+	dyndnsError   = "911"
+)
+
+// max number of hostnames in a single update
+const dyndnsMaxHosts = 20
+
+// DynDNSUser credentials of a DynDNS client and the hostnames it can update
+type DynDNSUser struct {
+	Password string
+	Hosts    []string
+}
+
+var dyndnsUsers = make(map[string]DynDNSUser)
+
+//LoadDynDNSUsers load the DynDNS clients from a file with lines in the format
+// `username:password[:host1,host2]`. Without hosts any hostname can be updated
+func LoadDynDNSUsers(path string) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	users := make(map[string]DynDNSUser)
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("Invalid DynDNS user at %s:%d", path, line)
+		}
+
+		user := DynDNSUser{Password: parts[1]}
+		if len(parts) == 3 {
+			for _, host := range strings.Split(parts[2], ",") {
+				host = strings.TrimSpace(host)
+				if host == "" {
+					continue
+				}
+				name, err := ddns.CanonicalName(host)
+				if err != nil {
+					return fmt.Errorf("Invalid DynDNS host at %s:%d: %s", path, line, host)
+				}
+				user.Hosts = append(user.Hosts, name)
+			}
+		}
+
+		users[parts[0]] = user
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	log.Debugf("Loaded %d DynDNS users", len(users))
+	dyndnsUsers = users
+
+	return nil
+}
+
+// canUpdate check if the user is allowed to update a hostname
+func (u DynDNSUser) canUpdate(hostname string) bool {
+
+	if len(u.Hosts) == 0 {
+		return true
+	}
+
+	hostname, err := ddns.CanonicalName(hostname)
+	if err != nil {
+		return false
+	}
+
+	for _, host := range u.Hosts {
+		if host == hostname {
+			return true
+		}
+	}
+
+	return false
+}
+
+func dyndnsAuth(req *http.Request) (DynDNSUser, bool) {
+
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return DynDNSUser{}, false
+	}
+
+	user, ok := dyndnsUsers[username]
+	if !ok {
+		return DynDNSUser{}, false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return DynDNSUser{}, false
+	}
+
+	return user, true
+}
+
+// dyndnsAddresses parse the myip parameter, falling back to the client address
+func dyndnsAddresses(req *http.Request) ([]net.IP, error) {
+
+	myip := req.URL.Query().Get("myip")
+	if myip == "" {
+		ip := httpCallerIP(req)
+		if ip == nil {
+			return nil, errors.New("Cannot detect caller IP")
+		}
+		return []net.IP{ip}, nil
+	}
+
+	ips := make([]net.IP, 0)
+	for _, item := range strings.Split(myip, ",") {
+		ip := net.ParseIP(strings.TrimSpace(item))
+		if ip == nil {
+			return nil, errors.New("Cannot parse IP: " + item)
+		}
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+// dyndnsFamilies group the addresses by family, IPv4 first, without duplicates
+func dyndnsFamilies(ips []net.IP) [][]net.IP {
+
+	v4 := make([]net.IP, 0)
+	v6 := make([]net.IP, 0)
+	seen := make(map[string]bool)
+	for _, ip := range ips {
+		if seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+
+	families := make([][]net.IP, 0, 2)
+	for _, family := range [][]net.IP{v4, v6} {
+		if len(family) > 0 {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// sameAddresses check if a record set has exactly the addresses
+func sameAddresses(rrs []dns.RR, ips []net.IP) bool {
+
+	if len(rrs) != len(ips) {
+		return false
+	}
+
+	stored := make(map[string]bool)
+	for _, rr := range rrs {
+		switch r := rr.(type) {
+		case *dns.A:
+			stored[r.A.String()] = true
+		case *dns.AAAA:
+			stored[r.AAAA.String()] = true
+		}
+	}
+
+	for _, ip := range ips {
+		if !stored[ip.String()] {
+			return false
+		}
+	}
+	return true
+}
+
+// dyndnsSave replace the record set of a hostname with the addresses of a
+// family, returns false if the stored set has the same addresses
+func dyndnsSave(ctx context.Context, server *ddnsServer, hostname string, ips []net.IP) (bool, error) {
+
+	rtype := "A"
+	qtype := dns.TypeA
+	if ips[0].To4() == nil {
+		rtype = "AAAA"
+		qtype = dns.TypeAAAA
+	}
+
+	if rrs, _, err := server.dns.GetRecordSet(hostname, qtype); err == nil && sameAddresses(rrs, ips) {
+		return false, nil
+	}
+
+	// replicas send the update to the primary, the user is allowed to write
+	// only the hostname
+	if identity, ok := IdentityFromContext(ctx); ok {
+		identity.Scopes = []string{ScopeWrite + ":" + hostname}
+		ctx = context.WithValue(ctx, identityKey{}, identity)
+	}
+
+	// the first address replace the set, the others are added to it
+	for i, ip := range ips {
+
+		msg := &Record{
+			Domain: hostname,
+			Ip:     ip.String(),
+			Type:   rtype,
+			Append: i > 0,
+		}
+
+		if _, forwarded, err := forwardWrite(ctx, "/api.DDNSService/SaveRecord", msg); forwarded {
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if _, err := server.SaveRecord(ctx, msg); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// dyndnsUpdate implement the DynDNS2 /nic/update protocol
+func dyndnsUpdate(server *ddnsServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+
+		w.Header().Set("Content-Type", "text/plain")
+
+		username, _, _ := req.BasicAuth()
+		clientIP := ""
+		if ip := httpCallerIP(req); ip != nil {
+			clientIP = ip.String()
+		}
+
+		user, ok := dyndnsAuth(req)
+		if !ok {
+			log.Debugf("DynDNS authentication failed from %s", req.RemoteAddr)
+			audit.Log(audit.Entry{
+				Actor:    username,
+				ClientIP: clientIP,
+				Source:   db.SourceAPI,
+				Action:   "dyndns_update",
+				Target:   req.URL.Query().Get("hostname"),
+				Outcome:  audit.OutcomeDenied,
+				Error:    "Authentication failed",
+			})
+			w.Header().Set("WWW-Authenticate", `Basic realm="ddns"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, dyndnsBadAuth)
+			return
+		}
+
+		// identify the user in the record history
+		ctx := context.WithValue(req.Context(), identityKey{}, Identity{
+			ID:   "dyndns:" + username,
+			Name: username,
+		})
+
+		hostnames := strings.Split(req.URL.Query().Get("hostname"), ",")
+		if len(hostnames) > dyndnsMaxHosts {
+			fmt.Fprintln(w, dyndnsNumHost)
+			return
+		}
+
+		ips, err := dyndnsAddresses(req)
+		if err != nil {
+			log.Debugf("DynDNS update failed: %s", err.Error())
+			fmt.Fprintln(w, dyndnsAbuse)
+			return
+		}
+
+		addresses := make([]string, len(ips))
+		for i, ip := range ips {
+			addresses[i] = ip.String()
+		}
+		address := strings.Join(addresses, ",")
+
+		for _, hostname := range hostnames {
+
+			hostname, err := ddns.CanonicalName(hostname)
+			if err != nil || dns.CountLabel(hostname) < 2 {
+				fmt.Fprintln(w, dyndnsNotFQDN)
+				continue
+			}
+
+			if !user.canUpdate(hostname) {
+				fmt.Fprintln(w, dyndnsNoHost)
+				continue
+			}
+
+			changed := false
+			failed := false
+			for _, family := range dyndnsFamilies(ips) {
+				c, err := dyndnsSave(ctx, server, hostname, family)
+
+				list := make([]string, len(family))
+				for i, ip := range family {
+					list[i] = ip.String()
+				}
+
+				entry := audit.Entry{
+					Actor:    username,
+					ClientIP: clientIP,
+					Source:   db.SourceAPI,
+					Action:   "dyndns_update",
+					Target:   hostname + " " + strings.Join(list, ","),
+					Outcome:  audit.OutcomeSuccess,
+				}
+				if err != nil {
+					entry.Outcome = audit.OutcomeFailure
+					entry.Error = err.Error()
+				}
+				if err != nil || c {
+					audit.Log(entry)
+				}
+
+				if err != nil {
+					log.Errorf("DynDNS update of %s failed: %s", hostname, err.Error())
+					failed = true
+					break
+				}
+				changed = changed || c
+			}
+
+			switch {
+			case failed:
+				fmt.Fprintln(w, dyndnsError)
+			case changed:
+				log.Debugf("DynDNS updated %s to %s", hostname, address)
+				fmt.Fprintf(w, "%s %s\n", dyndnsGood, address)
+			default:
+				fmt.Fprintf(w, "%s %s\n", dyndnsNoChg, address)
+			}
+		}
+	}
+}
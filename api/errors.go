@@ -0,0 +1,58 @@
+package api
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/muka/ddns/db"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invalidArgument return an InvalidArgument error with the violation of a request field
+func invalidArgument(field string, description string) error {
+
+	st := status.New(codes.InvalidArgument, description)
+	detailed, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: description},
+		},
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// toStatus map the errors returned by the handlers to gRPC status codes
+func toStatus(err error) error {
+
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case db.IsNotFound(err):
+		return status.Error(codes.NotFound, err.Error())
+	case db.IsExpired(err):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case err == db.ErrNotLeader:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	log.Errorf("Request failed: %s", err.Error())
+	return status.Error(codes.Internal, err.Error())
+}
+
+// errorInterceptor convert the errors of the gRPC calls to status errors
+func errorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	res, err := handler(ctx, req)
+	return res, toStatus(err)
+}
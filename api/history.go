@@ -0,0 +1,142 @@
+package api
+
+import (
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/miekg/dns"
+	"github.com/muka/ddns/db"
+	ddns "github.com/muka/ddns/dns"
+	log "github.com/sirupsen/logrus"
+)
+
+// origin identify the client of a request in the record history
+func origin(ctx context.Context) db.Origin {
+
+	o := db.Origin{Source: db.SourceAPI}
+
+	if identity, ok := IdentityFromContext(ctx); ok {
+		o.Actor = identity.Name
+	} else if ip, err := callerIP(ctx); err == nil {
+		o.Actor = ip.String()
+	}
+
+	return o
+}
+
+func recordValues(record *db.Record) []string {
+	if record == nil {
+		return nil
+	}
+	values := make([]string, 0)
+	for _, value := range record.GetValues() {
+		values = append(values, value.RR)
+	}
+	return values
+}
+
+func (s *ddnsServer) GetRecordHistory(ctx context.Context, msg *Record) (*RecordHistory, error) {
+	log.Debugf("History request %s %s", msg.GetType(), msg.GetDomain())
+
+	if msg.GetDomain() == "" {
+		return nil, invalidArgument("domain", "Domain is missing")
+	}
+
+	rtype, ok := dns.StringToType[strings.ToUpper(msg.GetType())]
+	if !ok {
+		return nil, invalidArgument("type", "Record type not supported: "+msg.GetType())
+	}
+
+	key, err := ddns.GetKey(msg.GetDomain(), rtype)
+	if err != nil {
+		return nil, invalidArgument("domain", err.Error())
+	}
+
+	changes, err := s.db.GetHistory(key)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &RecordHistory{
+		Domain: msg.GetDomain(),
+		Type:   dns.TypeToString[rtype],
+	}
+
+	for _, change := range changes {
+		entry := &HistoryEntry{
+			OldValues: recordValues(change.Old),
+			NewValues: recordValues(change.New),
+			Actor:     change.Actor,
+			Source:    change.Source,
+			Time:      change.Time,
+		}
+		if change.Old != nil {
+			entry.PreviousVersion = change.Old.ID
+		}
+		if change.New != nil {
+			entry.Version = change.New.ID
+		}
+		history.Entries = append(history.Entries, entry)
+	}
+
+	return history, nil
+}
+
+func (s *ddnsServer) RestoreRecord(ctx context.Context, msg *RestoreRequest) (*Record, error) {
+	log.Debugf("Restore request %s %s to %s", msg.GetType(), msg.GetDomain(), msg.GetVersion())
+
+	if msg.GetDomain() == "" {
+		return nil, invalidArgument("domain", "Domain is missing")
+	}
+
+	rtype, ok := dns.StringToType[strings.ToUpper(msg.GetType())]
+	if !ok {
+		return nil, invalidArgument("type", "Record type not supported: "+msg.GetType())
+	}
+
+	if msg.GetVersion() == "" {
+		return nil, invalidArgument("version", "Version is missing")
+	}
+
+	key, err := ddns.GetKey(msg.GetDomain(), rtype)
+	if err != nil {
+		return nil, invalidArgument("domain", err.Error())
+	}
+
+	ifMatch := ifMatch(ctx, &Record{IfMatch: msg.GetIfMatch()})
+
+	res := &Record{
+		Domain: msg.GetDomain(),
+		Type:   dns.TypeToString[rtype],
+	}
+
+	err = s.db.Update(origin(ctx), func(tx *db.Tx) error {
+
+		current, err := tx.GetRecord(key)
+		if err != nil && !db.IsNotFound(err) {
+			return err
+		}
+
+		if err := checkVersion(ifMatch, current, err == nil); err != nil {
+			return err
+		}
+
+		record, err := tx.GetVersion(key, msg.GetVersion())
+		if err != nil {
+			return err
+		}
+
+		// the restored records do not expire
+		record.Expires = 0
+		record.LeaseID = ""
+
+		res.Id, err = tx.StoreRecord(key, record)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// inprocessAddress is the dial target of the in-process gRPC connection
+const inprocessAddress = "inprocess"
+
+type inprocessAddr struct{}
+
+func (inprocessAddr) Network() string { return "pipe" }
+func (inprocessAddr) String() string  { return inprocessAddress }
+
+// pipeListener accept in-memory connections, used by the JSON gateway to reach
+// the gRPC service without a network hop
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+// inprocess is served by Run alongside the gRPC listener
+var inprocess = newPipeListener()
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, errors.New("In-process listener closed")
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return inprocessAddr{}
+}
+
+// dial open a connection to the listener, blocking until it is accepted
+func (l *pipeListener) dial(ctx context.Context, address string) (net.Conn, error) {
+
+	server, client := net.Pipe()
+	select {
+	case l.conns <- pipeConn{server}:
+		return pipeConn{client}, nil
+	case <-l.done:
+		return nil, errors.New("In-process listener closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pipeConn report the in-process address on both ends of the pipe
+type pipeConn struct {
+	net.Conn
+}
+
+func (pipeConn) LocalAddr() net.Addr  { return inprocessAddr{} }
+func (pipeConn) RemoteAddr() net.Addr { return inprocessAddr{} }
+
+// isInProcess check if a peer address belongs to the in-process connection
+func isInProcess(addr net.Addr) bool {
+	_, ok := addr.(inprocessAddr)
+	return ok
+}
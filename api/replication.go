@@ -0,0 +1,428 @@
+package api
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muka/ddns/db"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// replicationHeartbeat is the interval of the heartbeats sent to the replicas
+	replicationHeartbeat = 5 * time.Second
+	// replicationTimeout is the max time without messages before a replica reconnects
+	replicationTimeout = 3 * replicationHeartbeat
+	// replicationRetry is the delay before a replica reconnects
+	replicationRetry = 5 * time.Second
+)
+
+// Types of the replication events
+const (
+	eventSnapshot     = "snapshot"
+	eventSnapshotDone = "snapshot_done"
+	eventChange       = "change"
+	eventHeartbeat    = "heartbeat"
+)
+
+// forwardedByHeader mark the writes forwarded by a replica or a cluster node
+const forwardedByHeader = "x-ddns-forwarded"
+
+// onBehalfOfHeader carry the name of a client verified by a replica or a
+// cluster node without a token of its own, eg. by certificate, with its
+// scopes in onBehalfScopesHeader. Accepted only with a node token
+const (
+	onBehalfOfHeader     = "x-ddns-on-behalf-of"
+	onBehalfScopesHeader = "x-ddns-on-behalf-scopes"
+)
+
+// replica is set when running as a replica of a primary
+var replica *Replica
+
+// Replica apply the snapshot and the changes streamed by the primary
+type Replica struct {
+	primary string
+	token   string
+	forward bool
+	db      *db.DB
+	conn    *grpc.ClientConn
+	client  DDNSServiceClient
+	stop    chan struct{}
+
+	mu          sync.Mutex
+	connected   bool
+	seq         uint64
+	primarySeq  uint64
+	lag         time.Duration
+	lastContact time.Time
+}
+
+//RunReplica replicate the database of a primary. The token authenticate the
+// replica and the forwarded writes, rejected if forward is false
+func RunReplica(primary string, d *db.DB, token string, forward bool, opts ...grpc.DialOption) (*Replica, error) {
+
+	conn, err := grpc.Dial(primary, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replica{
+		primary: primary,
+		token:   token,
+		forward: forward,
+		db:      d,
+		conn:    conn,
+		client:  NewDDNSServiceClient(conn),
+		stop:    make(chan struct{}),
+	}
+	replica = r
+
+	go r.run()
+	return r, nil
+}
+
+//Stop stop the replication
+func (r *Replica) Stop() {
+	close(r.stop)
+	r.conn.Close()
+}
+
+func (r *Replica) run() {
+	for {
+		err := r.replicate()
+
+		r.mu.Lock()
+		r.connected = false
+		r.mu.Unlock()
+
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		log.Warnf("Replication from %s interrupted: %s", r.primary, err.Error())
+
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(replicationRetry):
+		}
+	}
+}
+
+// replicate load a snapshot of the primary and apply its changes, until the
+// stream is interrupted
+func (r *Replica) replicate() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// reconnect if the primary stops sending heartbeats
+	go func() {
+		ticker := time.NewTicker(replicationHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				cancel()
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.mu.Lock()
+				idle := time.Since(r.lastContact)
+				r.mu.Unlock()
+				if idle > replicationTimeout {
+					log.Warnf("No messages from %s for %s", r.primary, idle)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	if r.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, authorizationHeader, "Bearer "+r.token)
+	}
+
+	r.mu.Lock()
+	r.lastContact = time.Now()
+	r.mu.Unlock()
+
+	name, _ := os.Hostname()
+	stream, err := r.client.Replicate(ctx, &ReplicateRequest{Name: name})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Replicating from %s", r.primary)
+
+	dump := make([]db.Event, 0)
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		r.lastContact = time.Now()
+		r.mu.Unlock()
+
+		switch ev.GetType() {
+		case eventSnapshot:
+			dump = append(dump, db.Event{Bucket: ev.GetBucket(), Key: ev.GetKey(), Value: ev.GetValue()})
+
+		case eventSnapshotDone:
+			if err := r.db.Load(dump); err != nil {
+				return err
+			}
+			log.Infof("Loaded snapshot of %d keys from %s", len(dump), r.primary)
+			dump = nil
+
+			r.mu.Lock()
+			r.connected = true
+			r.seq = ev.GetSeq()
+			r.primarySeq = ev.GetSeq()
+			r.lag = 0
+			r.mu.Unlock()
+
+		case eventChange:
+			err := r.db.Apply(db.Event{
+				Bucket:  ev.GetBucket(),
+				Key:     ev.GetKey(),
+				Value:   ev.GetValue(),
+				Deleted: ev.GetDeleted(),
+			})
+			if err != nil {
+				return err
+			}
+
+			r.mu.Lock()
+			r.seq = ev.GetSeq()
+			if r.seq > r.primarySeq {
+				r.primarySeq = r.seq
+			}
+			r.lag = time.Since(time.Unix(0, ev.GetTime()))
+			r.mu.Unlock()
+
+		case eventHeartbeat:
+			r.mu.Lock()
+			r.primarySeq = ev.GetSeq()
+			if r.seq >= r.primarySeq {
+				r.lag = 0
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// status report the replication state of the replica
+func (r *Replica) status() *ReplicationStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := &ReplicationStatus{
+		Role:       "replica",
+		Primary:    r.primary,
+		Connected:  r.connected,
+		Seq:        r.seq,
+		PrimarySeq: r.primarySeq,
+		LagMs:      int64(r.lag / time.Millisecond),
+	}
+	if !r.lastContact.IsZero() {
+		st.LastContact = r.lastContact.Unix()
+	}
+
+	return st
+}
+
+func (s *ddnsServer) Replicate(msg *ReplicateRequest, stream DDNSService_ReplicateServer) error {
+
+	if replica != nil {
+		return status.Error(codes.FailedPrecondition, "Replicas can not be replicated, connect to the primary "+replica.primary)
+	}
+
+	// subscribe first, the changes committed during the snapshot are sent again
+	feed := s.db.Feed()
+	changes, cancel := feed.Subscribe()
+	defer cancel()
+
+	seq, _ := feed.Position()
+	log.Infof("Replica %s connected at change %d", msg.GetName(), seq)
+
+	err := s.db.Dump(func(e db.Event) error {
+		return stream.Send(&ReplicationEvent{
+			Type:   eventSnapshot,
+			Bucket: e.Bucket,
+			Key:    e.Key,
+			Value:  e.Value,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = stream.Send(&ReplicationEvent{Type: eventSnapshotDone, Seq: seq, Time: time.Now().UnixNano()})
+	if err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(replicationHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "Replica is too slow, reconnect to load a snapshot")
+			}
+			err = stream.Send(&ReplicationEvent{
+				Type:    eventChange,
+				Seq:     change.Seq,
+				Time:    change.Time.UnixNano(),
+				Bucket:  change.Bucket,
+				Key:     change.Key,
+				Value:   change.Value,
+				Deleted: change.Deleted,
+			})
+
+		case <-heartbeat.C:
+			seq, _ := feed.Position()
+			err = stream.Send(&ReplicationEvent{Type: eventHeartbeat, Seq: seq, Time: time.Now().UnixNano()})
+
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "The server is stopping")
+		case <-stream.Context().Done():
+			log.Infof("Replica %s disconnected", msg.GetName())
+			return nil
+		}
+
+		if err != nil {
+			log.Infof("Replica %s disconnected: %s", msg.GetName(), err.Error())
+			return err
+		}
+	}
+}
+
+func (s *ddnsServer) GetReplicationStatus(ctx context.Context, msg *ReplicationStatusRequest) (*ReplicationStatus, error) {
+
+	if replica != nil {
+		return replica.status(), nil
+	}
+
+	feed := s.db.Feed()
+	seq, _ := feed.Position()
+
+	return &ReplicationStatus{
+		Role:     "primary",
+		Seq:      seq,
+		Replicas: int32(feed.Subscribers()),
+	}, nil
+}
+
+// replicaWrites are the methods sent to the primary by the replicas
+var replicaWrites = map[string]func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error){
+	"/api.DDNSService/SaveRecord": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.SaveRecord(ctx, req.(*Record))
+	},
+	"/api.DDNSService/DeleteRecord": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.DeleteRecord(ctx, req.(*Record))
+	},
+	"/api.DDNSService/RenewLease": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.RenewLease(ctx, req.(*Lease))
+	},
+	"/api.DDNSService/RestoreRecord": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.RestoreRecord(ctx, req.(*RestoreRequest))
+	},
+	"/api.DDNSService/BatchUpdate": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.BatchUpdate(ctx, req.(*BatchRequest))
+	},
+	"/api.DDNSService/CreateToken": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.CreateToken(ctx, req.(*Token))
+	},
+	"/api.DDNSService/DeleteToken": func(ctx context.Context, c DDNSServiceClient, req interface{}) (interface{}, error) {
+		return c.DeleteToken(ctx, req.(*Token))
+	},
+}
+
+// forwardWrite send a write to the primary when running as a replica, or to
+// the leader when running in a cluster. Returns false if the write must be
+// handled locally
+func forwardWrite(ctx context.Context, method string, req interface{}) (interface{}, bool, error) {
+	if replica != nil {
+		return replica.forwardWrite(ctx, method, req)
+	}
+	if clusterNode != nil {
+		return clusterNode.forwardWrite(ctx, method, req)
+	}
+	return nil, false, nil
+}
+
+func (r *Replica) forwardWrite(ctx context.Context, method string, req interface{}) (interface{}, bool, error) {
+
+	write, ok := replicaWrites[method]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !r.forward {
+		return nil, true, status.Error(codes.FailedPrecondition, "Read-only replica, send the writes to the primary "+r.primary)
+	}
+
+	log.Debugf("Forwarding %s to %s", method, r.primary)
+	res, err := write(forwardContext(ctx, r.token), r.client, req)
+	return res, true, err
+}
+
+// forwardContext return the metadata of a forwarded write. The credential of
+// the client is forwarded as is. The clients verified by the node without a
+// token, by certificate or DynDNS password, are passed with their scopes
+// along the token of the node, the other clients are forwarded anonymous
+func forwardContext(ctx context.Context, token string) context.Context {
+
+	md := metadata.MD{}
+	if in, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := in.Get(authorizationHeader); len(values) > 0 {
+			md.Set(authorizationHeader, values[0])
+		}
+	}
+
+	if len(md.Get(authorizationHeader)) == 0 && token != "" {
+		if identity, ok := IdentityFromContext(ctx); ok && len(identity.Scopes) > 0 {
+			md.Set(authorizationHeader, "Bearer "+token)
+			md.Set(onBehalfOfHeader, identity.Name)
+			md.Set(onBehalfScopesHeader, strings.Join(identity.Scopes, ","))
+		}
+	}
+	if value := ifMatch(ctx, &Record{}); value != "" {
+		md.Set(ifMatchHeader, value)
+	}
+	if ip, err := callerIP(ctx); err == nil {
+		md.Set(forwardedHeader, ip.String())
+	}
+	md.Set(forwardedByHeader, "true")
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// isForwarded check if a write was forwarded by another node
+func isForwarded(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	return ok && len(md.Get(forwardedByHeader)) > 0
+}
+
+// replicaInterceptor forward the writes received by a replica to the primary
+func replicaInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if res, forwarded, err := forwardWrite(ctx, info.FullMethod, req); forwarded {
+		return res, err
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	ddns "github.com/muka/ddns/dns"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// clientCertHeader carry the client certificate name verified by the JSON gateway
+const clientCertHeader = "x-ddns-client-cert"
+
+var (
+	tlsConfig   *tls.Config
+	clientCerts = make(map[string][]string)
+)
+
+//SetTLS enable TLS on the gRPC and JSON API. If a CA is provided, client
+// certificates signed by it are verified and can be used to authenticate
+func SetTLS(certFile string, keyFile string, caFile string) error {
+
+	if certFile == "" || keyFile == "" {
+		return errors.New("TLS certificate and key are required")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("No certificate found in " + caFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	log.Debugf("TLS enabled (client certificates: %t)", caFile != "")
+	tlsConfig = config
+
+	return nil
+}
+
+//LoadClientCerts load the names of the client certificates from a file with
+// lines in the format `name:suffix1,suffix2`. The name is matched against the
+// certificate CN and DNS SANs, the suffixes are the domains it can update
+func LoadClientCerts(path string) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	certs := make(map[string][]string)
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, ":", 2)
+		if len(parts) < 2 || parts[0] == "" {
+			return fmt.Errorf("Invalid client certificate at %s:%d", path, line)
+		}
+
+		suffixes := make([]string, 0)
+		for _, suffix := range strings.Split(parts[1], ",") {
+			suffix = strings.ToLower(strings.TrimSpace(suffix))
+			if suffix == "" {
+				continue
+			}
+			name, err := ddns.CanonicalName(suffix)
+			if err != nil {
+				return fmt.Errorf("Invalid domain at %s:%d: %s", path, line, suffix)
+			}
+			suffixes = append(suffixes, name)
+		}
+
+		certs[strings.ToLower(parts[0])] = suffixes
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	log.Debugf("Loaded %d client certificates", len(certs))
+	clientCerts = certs
+
+	return nil
+}
+
+// certName return the first name of a verified certificate listed in the client certificates
+func certName(state *tls.ConnectionState) (string, bool) {
+
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	leaf := state.VerifiedChains[0][0]
+	names := append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if _, ok := clientCerts[name]; ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// certIdentity return the identity of a client certificate name
+func certIdentity(name string) (Identity, bool) {
+
+	suffixes, ok := clientCerts[name]
+	if !ok {
+		return Identity{}, false
+	}
+
+	scopes := []string{ScopeRead}
+	for _, suffix := range suffixes {
+		scopes = append(scopes, ScopeWrite+":"+suffix)
+	}
+
+	return Identity{
+		ID:     "cert:" + name,
+		Name:   name,
+		Scopes: scopes,
+	}, true
+}
+
+// authenticateCert resolve the client certificate of a gRPC call, either
+// verified on the connection or forwarded by the in-process JSON gateway
+func authenticateCert(ctx context.Context) (Identity, bool) {
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return Identity{}, false
+	}
+
+	if isInProcess(p.Addr) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return Identity{}, false
+		}
+		values := md.Get(clientCertHeader)
+		if len(values) == 0 {
+			return Identity{}, false
+		}
+		return certIdentity(values[0])
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return Identity{}, false
+	}
+
+	name, ok := certName(&info.State)
+	if !ok {
+		return Identity{}, false
+	}
+
+	return certIdentity(name)
+}
+
+// gatewayHeaderMatcher forward the default headers, except the client
+// certificate name which is set only from the verified HTTP connection
+func gatewayHeaderMatcher(key string) (string, bool) {
+	name, ok := runtime.DefaultHeaderMatcher(key)
+	if ok && strings.ToLower(name) == clientCertHeader {
+		return "", false
+	}
+	return name, ok
+}
+
+// gatewayMetadata forward the verified client certificate of an HTTP request
+func gatewayMetadata(ctx context.Context, req *http.Request) metadata.MD {
+	if name, ok := certName(req.TLS); ok {
+		return metadata.Pairs(clientCertHeader, name)
+	}
+	return nil
+}
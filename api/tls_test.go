@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGatewayHeaderMatcher(t *testing.T) {
+
+	// the client certificate name can not be set by the HTTP clients
+	for _, header := range []string{"Grpc-Metadata-X-Ddns-Client-Cert", "grpc-metadata-x-ddns-client-cert", "X-Ddns-Client-Cert"} {
+		if name, ok := gatewayHeaderMatcher(header); ok && strings.EqualFold(name, clientCertHeader) {
+			t.Errorf("Header %s forwarded as %s", header, name)
+		}
+	}
+
+	// the other metadata is forwarded
+	if name, ok := gatewayHeaderMatcher("Grpc-Metadata-X-Request-Id"); !ok || name != "X-Request-Id" {
+		t.Errorf("Metadata forwarded as %s, %t", name, ok)
+	}
+}
+
+func TestAuthenticateCertForged(t *testing.T) {
+
+	previous := clientCerts
+	defer func() { clientCerts = previous }()
+	clientCerts = map[string][]string{"router.lan": {"lan."}}
+
+	md := metadata.Pairs(clientCertHeader, "router.lan")
+
+	// forwarded by the in-process gateway
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: inprocessAddr{}})
+	identity, ok := authenticateCert(metadata.NewIncomingContext(ctx, md))
+	if !ok || identity.Name != "router.lan" {
+		t.Errorf("Certificate forwarded by the gateway not accepted: %+v", identity)
+	}
+
+	// sent by a remote client without a verified certificate
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4000}})
+	if identity, ok := authenticateCert(metadata.NewIncomingContext(ctx, md)); ok {
+		t.Errorf("Forged certificate header accepted as %+v", identity)
+	}
+
+	s := testServer()
+	if _, err := s.authenticate(metadata.NewIncomingContext(ctx, md)); err == nil {
+		t.Errorf("Forged certificate header authenticated")
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/muka/ddns/db"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// size of the generated token secrets in bytes
+const tokenSize = 32
+
+func newTokenSecret() (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *ddnsServer) CreateToken(ctx context.Context, msg *Token) (*Token, error) {
+	log.Debugf("Create token %s %v", msg.GetName(), msg.GetScopes())
+
+	if msg.GetName() == "" {
+		return nil, invalidArgument("name", "Name is missing")
+	}
+
+	scopes, err := parseScopes(msg.GetScopes())
+	if err != nil {
+		return nil, invalidArgument("scopes", err.Error())
+	}
+
+	tokens, err := s.db.GetTokens()
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		if token.Name == msg.GetName() {
+			return nil, status.Error(codes.AlreadyExists, "Token already exists: "+msg.GetName())
+		}
+	}
+
+	secret, err := newTokenSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	token := db.Token{
+		ID:      db.NewTokenID(),
+		Name:    msg.GetName(),
+		Scopes:  scopes,
+		Created: time.Now().Unix(),
+	}
+
+	err = s.db.StoreToken(hashToken(secret), token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		Id:      token.ID,
+		Name:    token.Name,
+		Scopes:  token.Scopes,
+		Token:   secret,
+		Created: token.Created,
+	}, nil
+}
+
+func (s *ddnsServer) ListTokens(ctx context.Context, msg *ListTokensRequest) (*TokenList, error) {
+
+	tokens, err := s.db.GetTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	list := &TokenList{}
+	for _, token := range tokens {
+		list.Tokens = append(list.Tokens, &Token{
+			Id:      token.ID,
+			Name:    token.Name,
+			Scopes:  token.Scopes,
+			Created: token.Created,
+		})
+	}
+
+	return list, nil
+}
+
+func (s *ddnsServer) DeleteToken(ctx context.Context, msg *Token) (*Token, error) {
+	log.Debugf("Delete token %s", msg.GetId())
+
+	if msg.GetId() == "" {
+		return nil, invalidArgument("id", "Token ID is missing")
+	}
+
+	err := s.db.DeleteToken(msg.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
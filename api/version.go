@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/muka/ddns/db"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ifMatchHeader is forwarded by the JSON gateway from the HTTP If-Match header
+const ifMatchHeader = "grpcgateway-if-match"
+
+// ifMatch return the version precondition of a request, from the record or the If-Match header
+func ifMatch(ctx context.Context, msg *Record) string {
+
+	if msg.GetIfMatch() != "" {
+		return msg.GetIfMatch()
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ifMatchHeader); len(values) > 0 {
+			return strings.Join(values, ",")
+		}
+	}
+
+	return ""
+}
+
+// checkVersion verify the version precondition against the stored record
+func checkVersion(ifMatch string, record db.Record, found bool) error {
+
+	if ifMatch == "" {
+		return nil
+	}
+
+	for _, tag := range strings.Split(ifMatch, ",") {
+
+		tag = strings.TrimSpace(tag)
+		tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+
+		if tag == "*" && found {
+			return nil
+		}
+		if found && tag == record.ID {
+			return nil
+		}
+	}
+
+	if !found {
+		return status.Error(codes.Aborted, "Record does not exist")
+	}
+
+	return status.Error(codes.Aborted, "Record version does not match, current is "+record.ID)
+}
+
+// gatewayETag set the ETag header to the version of the returned record
+func gatewayETag(ctx context.Context, w http.ResponseWriter, msg proto.Message) error {
+
+	var version string
+	switch m := msg.(type) {
+	case *Record:
+		version = m.GetId()
+	case *RecordSet:
+		version = m.GetId()
+	}
+
+	if version != "" {
+		w.Header().Set("ETag", `"`+version+`"`)
+	}
+
+	return nil
+}
+
+// preconditionWriter replace the status code of an aborted request
+type preconditionWriter struct {
+	http.ResponseWriter
+}
+
+func (w preconditionWriter) WriteHeader(code int) {
+	w.ResponseWriter.WriteHeader(http.StatusPreconditionFailed)
+}
+
+// gatewayError return 412 Precondition Failed on version mismatch
+func gatewayError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if status.Code(err) == codes.Aborted {
+		w = preconditionWriter{w}
+	}
+	runtime.DefaultHTTPError(ctx, mux, marshaler, w, r, err)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestSaveRecordIfMatch(t *testing.T) {
+
+	s := testServer()
+	ctx := context.Background()
+
+	saved, err := s.SaveRecord(ctx, &Record{Domain: "a.local.lan", Type: "A", Ip: "10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := saved.GetId()
+
+	// the version is read from the record or the If-Match header
+	stale := metadata.NewIncomingContext(ctx, metadata.Pairs(ifMatchHeader, `"stale"`))
+	if _, err := s.SaveRecord(stale, &Record{Domain: "a.local.lan", Type: "A", Ip: "10.0.0.2"}); status.Code(err) != codes.Aborted {
+		t.Errorf("Save with a stale If-Match returned %v", err)
+	}
+	if _, err := s.SaveRecord(ctx, &Record{Domain: "b.local.lan", Type: "A", Ip: "10.0.0.2", IfMatch: "*"}); status.Code(err) != codes.Aborted {
+		t.Errorf("Save of a missing record with If-Match * returned %v", err)
+	}
+
+	current := metadata.NewIncomingContext(ctx, metadata.Pairs(ifMatchHeader, `"`+version+`"`))
+	if _, err := s.SaveRecord(current, &Record{Domain: "a.local.lan", Type: "A", Ip: "10.0.0.2"}); err != nil {
+		t.Errorf("Save with the current If-Match failed: %v", err)
+	}
+}
+
+func TestGatewayErrorPreconditionFailed(t *testing.T) {
+
+	mux := runtime.NewServeMux()
+	req := httptest.NewRequest(http.MethodPost, "/v1/record", nil)
+
+	w := httptest.NewRecorder()
+	gatewayError(context.Background(), mux, &runtime.JSONPb{}, w, req, status.Error(codes.Aborted, "Record version does not match"))
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Version mismatch returned %d, want 412", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	gatewayError(context.Background(), mux, &runtime.JSONPb{}, w, req, status.Error(codes.NotFound, "Record not found"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Not found returned %d, want 404", w.Code)
+	}
+}
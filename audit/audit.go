@@ -0,0 +1,361 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Outcomes of an audited operation
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// Entry of the audit log. Hash is computed on the entry including the hash
+// of the previous one, so a removed or changed entry breaks the chain
+type Entry struct {
+	Time     string `json:"time"`
+	Actor    string `json:"actor,omitempty"`
+	ClientIP string `json:"client_ip,omitempty"`
+	Source   string `json:"source"`
+	Action   string `json:"action"`
+	Target   string `json:"target,omitempty"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+	Prev     string `json:"prev"`
+	Hash     string `json:"hash"`
+}
+
+// Filter of the audit log query, empty fields match any entry
+type Filter struct {
+	Since  time.Time
+	Until  time.Time
+	Actor  string
+	Target string
+	Source string
+	Limit  int
+}
+
+// Result of an audit log query
+type Result struct {
+	Entries []Entry
+	// Valid is false if the hash chain is broken
+	Valid bool
+	Error string
+}
+
+var (
+	mu       sync.Mutex
+	file     *os.File
+	path     string
+	size     int64
+	maxSize  int64
+	maxFiles int
+	last     string
+)
+
+//Open start writing the audit log to a file, rotated when larger than
+// maxSizeMB keeping the last keep files
+func Open(logPath string, maxSizeMB int, keep int) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if maxSizeMB <= 0 || keep < 1 {
+		return errors.New("Invalid audit log rotation")
+	}
+
+	path = logPath
+	maxSize = int64(maxSizeMB) * 1024 * 1024
+	maxFiles = keep
+
+	// continue the chain of the previous entries
+	for _, name := range files() {
+		entries, err := readFile(name)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			last = entries[len(entries)-1].Hash
+		}
+	}
+
+	if err := open(); err != nil {
+		return err
+	}
+
+	log.Debugf("Writing audit log to %s", path)
+	return nil
+}
+
+//Close stop writing the audit log
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+
+	err := file.Close()
+	file = nil
+	return err
+}
+
+//Log append an entry to the audit log, if enabled
+func Log(e Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return
+	}
+
+	if e.Time == "" {
+		e.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	e.Prev = last
+	e.Hash = hash(e)
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("Audit log failed: %s", err.Error())
+		return
+	}
+
+	n, err := file.Write(append(line, '\n'))
+	if err != nil {
+		log.Errorf("Audit log failed: %s", err.Error())
+		return
+	}
+
+	last = e.Hash
+	size += int64(n)
+
+	if size >= maxSize {
+		if err := rotate(); err != nil {
+			log.Errorf("Audit log rotation failed: %s", err.Error())
+		}
+	}
+}
+
+// logFile is a log file opened by a query, read up to its size at open time
+type logFile struct {
+	name string
+	file *os.File
+	size int64
+}
+
+// openFiles open the existing log files, the oldest first. The open files
+// are read while new entries are logged and the files rotated
+func openFiles() ([]logFile, error) {
+
+	list := make([]logFile, 0)
+	for _, name := range files() {
+
+		f, err := os.Open(name)
+		if err != nil {
+			closeFiles(list)
+			return nil, err
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			closeFiles(list)
+			return nil, err
+		}
+
+		list = append(list, logFile{name: name, file: f, size: info.Size()})
+	}
+
+	return list, nil
+}
+
+func closeFiles(list []logFile) {
+	for _, l := range list {
+		l.file.Close()
+	}
+}
+
+//Query return the entries matching a filter, verifying the hash chain
+func Query(filter Filter) (Result, error) {
+
+	res := Result{Valid: true}
+
+	// only the files are opened under the lock, the entries are read without
+	// blocking the operations being logged
+	mu.Lock()
+	if path == "" {
+		mu.Unlock()
+		return res, errors.New("Audit log is not enabled")
+	}
+	logs, err := openFiles()
+	mu.Unlock()
+	if err != nil {
+		return res, err
+	}
+	defer closeFiles(logs)
+
+	prev := ""
+	first := true
+	for _, l := range logs {
+
+		name := l.name
+		entries, err := readEntries(name, io.LimitReader(l.file, l.size))
+		if err != nil {
+			return res, err
+		}
+
+		for _, e := range entries {
+
+			// the chain of the oldest entry is lost with the rotated files
+			if res.Valid && ((!first && e.Prev != prev) || hash(e) != e.Hash) {
+				res.Valid = false
+				res.Error = fmt.Sprintf("Hash chain broken at %s entry of %s", e.Time, name)
+			}
+			prev = e.Hash
+			first = false
+
+			if filter.match(e) {
+				res.Entries = append(res.Entries, e)
+			}
+		}
+	}
+
+	if filter.Limit > 0 && len(res.Entries) > filter.Limit {
+		res.Entries = res.Entries[len(res.Entries)-filter.Limit:]
+	}
+
+	return res, nil
+}
+
+func (f Filter) match(e Entry) bool {
+
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		t, err := time.Parse(time.RFC3339Nano, e.Time)
+		if err != nil {
+			return false
+		}
+		if !f.Since.IsZero() && t.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && t.After(f.Until) {
+			return false
+		}
+	}
+
+	if f.Actor != "" && f.Actor != e.Actor {
+		return false
+	}
+	if f.Source != "" && f.Source != e.Source {
+		return false
+	}
+	if f.Target != "" && !strings.Contains(e.Target, f.Target) {
+		return false
+	}
+
+	return true
+}
+
+func hash(e Entry) string {
+	e.Hash = ""
+	raw, _ := json.Marshal(e)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func open() error {
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	file = f
+	size = info.Size()
+	return nil
+}
+
+// rotate rename the log files, dropping the oldest one
+func rotate() error {
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+	file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", path, maxFiles))
+	for i := maxFiles - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		return err
+	}
+
+	return open()
+}
+
+// files return the existing log files, the oldest first
+func files() []string {
+	list := make([]string, 0)
+	for i := maxFiles; i > 0; i-- {
+		name := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(name); err == nil {
+			list = append(list, name)
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		list = append(list, path)
+	}
+	return list
+}
+
+func readFile(name string) ([]Entry, error) {
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readEntries(name, f)
+}
+
+// readEntries parse the entries of a log file
+func readEntries(name string, r io.Reader) ([]Entry, error) {
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		e := Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("Invalid audit entry in %s: %s", name, err.Error())
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
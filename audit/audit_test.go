@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openTemp open an audit log in a temporary directory, closed at the end of the test
+func openTemp(t *testing.T) string {
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := filepath.Join(dir, "audit.log")
+	if err := Open(name, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		Close()
+		path = ""
+		last = ""
+		os.RemoveAll(dir)
+	})
+
+	return name
+}
+
+// entryTime return the time of the n-th test entry
+func entryTime(n int) string {
+	return fmt.Sprintf("2026-01-01T00:00:%02dZ", n)
+}
+
+func logEntries(count int) {
+	for i := 0; i < count; i++ {
+		Log(Entry{
+			Time:    entryTime(i),
+			Actor:   "test",
+			Source:  "api",
+			Action:  "save_record",
+			Target:  fmt.Sprintf("host%d.local.lan A", i),
+			Outcome: OutcomeSuccess,
+		})
+	}
+}
+
+// editLines change the lines of a log file
+func editLines(t *testing.T, name string, edit func(lines []string) []string) {
+
+	raw, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	lines = edit(lines)
+
+	if err := ioutil.WriteFile(name, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkBroken verify the chain is reported broken at an entry
+func checkBroken(t *testing.T, at string) {
+	t.Helper()
+
+	res, err := Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Valid {
+		t.Fatalf("Chain not broken, entry %s changed", at)
+	}
+	if !strings.Contains(res.Error, at) {
+		t.Errorf("Chain broken %q, want at %s", res.Error, at)
+	}
+}
+
+func TestChainValid(t *testing.T) {
+
+	openTemp(t)
+	logEntries(5)
+
+	res, err := Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || len(res.Entries) != 5 {
+		t.Errorf("Query returned %d entries, valid %t: %s", len(res.Entries), res.Valid, res.Error)
+	}
+
+	res, err = Query(Filter{Target: "host3", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Entries) != 1 || res.Entries[0].Time != entryTime(3) {
+		t.Errorf("Filtered query returned %+v", res.Entries)
+	}
+}
+
+func TestChainDeletedLine(t *testing.T) {
+
+	name := openTemp(t)
+	logEntries(5)
+
+	editLines(t, name, func(lines []string) []string {
+		return append(lines[:2:2], lines[3:]...)
+	})
+
+	// the entry after the deleted one does not follow the chain
+	checkBroken(t, entryTime(3))
+}
+
+func TestChainEditedLine(t *testing.T) {
+
+	name := openTemp(t)
+	logEntries(5)
+
+	editLines(t, name, func(lines []string) []string {
+		lines[1] = strings.Replace(lines[1], "save_record", "delete_record", 1)
+		return lines
+	})
+
+	checkBroken(t, entryTime(1))
+}
+
+func TestChainRotated(t *testing.T) {
+
+	name := openTemp(t)
+
+	// rotate every few entries
+	mu.Lock()
+	maxSize = 600
+	mu.Unlock()
+	logEntries(8)
+
+	if _, err := os.Stat(name + ".2"); err != nil {
+		t.Fatalf("Log not rotated: %s", err)
+	}
+
+	res, err := Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || len(res.Entries) != 8 {
+		t.Fatalf("Rotated query returned %d entries, valid %t: %s", len(res.Entries), res.Valid, res.Error)
+	}
+
+	// the last entry of the older file is removed, the chain breaks at the
+	// first entry of the next file
+	next, err := readFile(name + ".1")
+	if err != nil || len(next) == 0 {
+		t.Fatalf("Rotated file not readable: %v", err)
+	}
+	editLines(t, name+".2", func(lines []string) []string {
+		return lines[:len(lines)-1]
+	})
+	checkBroken(t, fmt.Sprintf("%s entry of %s.1", next[0].Time, name))
+}
+
+func TestChainRotatedEdited(t *testing.T) {
+
+	name := openTemp(t)
+
+	mu.Lock()
+	maxSize = 600
+	mu.Unlock()
+	logEntries(8)
+
+	rotated, err := readFile(name + ".1")
+	if err != nil || len(rotated) == 0 {
+		t.Fatalf("Log not rotated: %v", err)
+	}
+
+	editLines(t, name+".1", func(lines []string) []string {
+		lines[0] = strings.Replace(lines[0], "host", "evil", 1)
+		return lines
+	})
+	checkBroken(t, rotated[0].Time)
+}
@@ -1,29 +1,49 @@
 package main
 
 import (
-	"net"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/miekg/dns"
 	"github.com/muka/ddns/api"
+	"github.com/muka/ddns/audit"
+	"github.com/muka/ddns/config"
 	coredns_grpc "github.com/muka/ddns/coredns"
 	"github.com/muka/ddns/db"
 	ddns "github.com/muka/ddns/dns"
+	"github.com/muka/ddns/health"
+	"github.com/muka/ddns/supervisor"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const timerSeconds = 15
+const configWatchInterval = 5 * time.Second
+const historyPruneInterval = time.Hour
 
 func main() {
 
 	app := cli.NewApp()
 
 	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:   "config",
+			Usage:  "YAML file of the listeners, zones, TSIG keys, ACLs and forwarding, reloaded on SIGHUP or change",
+			EnvVar: "CONFIG",
+		},
 		cli.StringFlag{
 			Name:   "http-server, s",
 			Value:  ":5551",
@@ -42,6 +62,25 @@ func main() {
 			Usage:  "location where db will be stored",
 			EnvVar: "DBPATH",
 		},
+		cli.StringFlag{
+			Name:   "storage",
+			Usage:  "Storage backend as bolt://path, sqlite://path or memory://, defaults to the bolt file at dbpath",
+			EnvVar: "STORAGE",
+		},
+		cli.StringFlag{
+			Name:   "encryption-key-file",
+			Usage:  "File with the 32 bytes key, in base64 or hex, to encrypt the stored values",
+			EnvVar: "ENCRYPTION_KEY_FILE",
+		},
+		cli.StringFlag{
+			Name:   "encryption-key",
+			Usage:  "32 bytes key, in base64 or hex, to encrypt the stored values. Prefer encryption-key-file",
+			EnvVar: "ENCRYPTION_KEY",
+		},
+		cli.BoolFlag{
+			Name:  "migrate-dry-run",
+			Usage: "Check the pending schema migrations of the storage without applying them, then exit",
+		},
 		cli.IntFlag{
 			Name:   "port, p",
 			Value:  10053,
@@ -60,6 +99,192 @@ func main() {
 			Usage:  "Expose CoreDNS gRPC endpoint (will disable internal DNS)",
 			EnvVar: "COREDNS",
 		},
+		cli.StringFlag{
+			Name:   "allow-query",
+			Value:  "any",
+			Usage:  "Comma separated list of networks allowed to query the DNS (any, none or CIDR)",
+			EnvVar: "ALLOW_QUERY",
+		},
+		cli.StringFlag{
+			Name:   "allow-update",
+			Value:  "any",
+			Usage:  "Comma separated list of networks allowed to send DNS updates (any, none or CIDR)",
+			EnvVar: "ALLOW_UPDATE",
+		},
+		cli.StringFlag{
+			Name:   "allow-recursion",
+			Value:  ddns.DefaultRecursionACL,
+			Usage:  "Comma separated list of networks allowed to send the queries forwarded to the upstream servers (any, none or CIDR)",
+			EnvVar: "ALLOW_RECURSION",
+		},
+		cli.IntFlag{
+			Name:   "health-interval",
+			Value:  10,
+			Usage:  "Seconds between records health checks, 0 to disable",
+			EnvVar: "HEALTH_INTERVAL",
+		},
+		cli.StringFlag{
+			Name:   "trusted-proxies",
+			Value:  "127.0.0.0/8,::1",
+			Usage:  "Comma separated list of proxy networks allowed to forward the client address with X-Forwarded-For, empty to trust none",
+			EnvVar: "TRUSTED_PROXIES",
+		},
+		cli.StringFlag{
+			Name:   "dyndns-users",
+			Value:  "",
+			Usage:  "File with DynDNS clients credentials, one username:password[:host1,host2] per line",
+			EnvVar: "DYNDNS_USERS",
+		},
+		cli.BoolFlag{
+			Name:   "auth",
+			Usage:  "Require a bearer token for the API calls",
+			EnvVar: "AUTH",
+		},
+		cli.StringFlag{
+			Name:   "tokens",
+			Value:  "",
+			Usage:  "File with static API tokens, one name:token:scope1,scope2 per line",
+			EnvVar: "TOKENS",
+		},
+		cli.StringFlag{
+			Name:   "tls-cert",
+			Value:  "",
+			Usage:  "TLS certificate for the gRPC and HTTP API",
+			EnvVar: "TLS_CERT",
+		},
+		cli.StringFlag{
+			Name:   "tls-key",
+			Value:  "",
+			Usage:  "TLS private key for the gRPC and HTTP API",
+			EnvVar: "TLS_KEY",
+		},
+		cli.StringFlag{
+			Name:   "tls-ca",
+			Value:  "",
+			Usage:  "CA to verify the API client certificates",
+			EnvVar: "TLS_CA",
+		},
+		cli.StringFlag{
+			Name:   "client-certs",
+			Value:  "",
+			Usage:  "File with the allowed client certificates, one name:suffix1,suffix2 per line",
+			EnvVar: "CLIENT_CERTS",
+		},
+		cli.StringFlag{
+			Name:   "primary",
+			Usage:  "gRPC address of the primary, to run as a replica",
+			EnvVar: "PRIMARY",
+		},
+		cli.StringFlag{
+			Name:   "primary-token",
+			Usage:  "API token with the admin scope to replicate from the primary and forward the writes",
+			EnvVar: "PRIMARY_TOKEN",
+		},
+		cli.StringFlag{
+			Name:   "primary-ca",
+			Usage:  "CA to verify the primary certificate, connect without TLS if empty",
+			EnvVar: "PRIMARY_CA",
+		},
+		cli.StringFlag{
+			Name:   "replica-writes",
+			Value:  "forward",
+			Usage:  "Writes received by a replica, one of forward (to the primary) or reject",
+			EnvVar: "REPLICA_WRITES",
+		},
+		cli.StringFlag{
+			Name:   "cluster-bind",
+			Usage:  "Raft host:port of the node, reachable by the other nodes, to run in a cluster",
+			EnvVar: "CLUSTER_BIND",
+		},
+		cli.StringFlag{
+			Name:   "cluster-id",
+			Usage:  "Unique ID of the cluster node, defaults to the hostname",
+			EnvVar: "CLUSTER_ID",
+		},
+		cli.StringFlag{
+			Name:   "cluster-dir",
+			Value:  "./data/raft",
+			Usage:  "Directory of the Raft log and snapshots",
+			EnvVar: "CLUSTER_DIR",
+		},
+		cli.BoolFlag{
+			Name:   "cluster-bootstrap",
+			Usage:  "Start a new cluster with this node, seeded with its database",
+			EnvVar: "CLUSTER_BOOTSTRAP",
+		},
+		cli.StringFlag{
+			Name:   "cluster-join",
+			Usage:  "gRPC address of a cluster node, to join its cluster",
+			EnvVar: "CLUSTER_JOIN",
+		},
+		cli.StringFlag{
+			Name:   "cluster-api",
+			Usage:  "gRPC address of the node advertised to the other nodes, defaults to grpc-server",
+			EnvVar: "CLUSTER_API",
+		},
+		cli.StringFlag{
+			Name:   "cluster-token",
+			Usage:  "API token with the admin scope to join the cluster and forward the writes to the leader",
+			EnvVar: "CLUSTER_TOKEN",
+		},
+		cli.StringFlag{
+			Name:   "cluster-ca",
+			Usage:  "CA of the cluster, to run the Raft transport over mutual TLS with the tls-cert certificate and verify the other nodes. Without it the cluster-bind must be loopback",
+			EnvVar: "CLUSTER_CA",
+		},
+		cli.IntFlag{
+			Name:   "history-limit",
+			Value:  20,
+			Usage:  "Max number of changes kept in the history of each record, 0 to keep all",
+			EnvVar: "HISTORY_LIMIT",
+		},
+		cli.IntFlag{
+			Name:   "history-retention",
+			Value:  30,
+			Usage:  "Days the record changes are kept in the history, 0 to keep them",
+			EnvVar: "HISTORY_RETENTION",
+		},
+		cli.StringFlag{
+			Name:   "audit-log",
+			Value:  "",
+			Usage:  "File of the audit log, disabled if empty",
+			EnvVar: "AUDIT_LOG",
+		},
+		cli.IntFlag{
+			Name:   "audit-max-size",
+			Value:  100,
+			Usage:  "Size in MB after which the audit log is rotated",
+			EnvVar: "AUDIT_MAX_SIZE",
+		},
+		cli.IntFlag{
+			Name:   "audit-keep",
+			Value:  10,
+			Usage:  "Number of rotated audit log files to keep",
+			EnvVar: "AUDIT_KEEP",
+		},
+		cli.StringFlag{
+			Name:   "snapshot-dir",
+			Usage:  "Directory of the scheduled database snapshots, disabled if empty",
+			EnvVar: "SNAPSHOT_DIR",
+		},
+		cli.IntFlag{
+			Name:   "snapshot-interval",
+			Value:  24,
+			Usage:  "Interval in hours between the database snapshots",
+			EnvVar: "SNAPSHOT_INTERVAL",
+		},
+		cli.IntFlag{
+			Name:   "snapshot-keep",
+			Value:  7,
+			Usage:  "Number of database snapshots to keep",
+			EnvVar: "SNAPSHOT_KEEP",
+		},
+		cli.IntFlag{
+			Name:   "shutdown-timeout",
+			Value:  10,
+			Usage:  "Seconds to wait the in-flight requests when stopping",
+			EnvVar: "SHUTDOWN_TIMEOUT",
+		},
 		cli.BoolFlag{
 			Name:   "debug",
 			Usage:  "Enable debug",
@@ -70,7 +295,7 @@ func main() {
 	app.Action = func(c *cli.Context) error {
 
 		debug := c.Bool("debug")
-		dbPath := c.String("dbpath")
+		storage := storageURL(c)
 		ip := c.String("ip")
 		port := c.Int("port")
 		httpServer := c.String("http-server")
@@ -81,94 +306,675 @@ func main() {
 			log.SetLevel(log.DebugLevel)
 		}
 
-		log.Debugf("Connecting to %s", dbPath)
-		err1 := db.Connect(dbPath)
-		if err1 != nil {
-			panic(err1)
+		configFile := c.String("config")
+		conf := &config.Config{}
+		if configFile != "" {
+			var err error
+			if conf, err = config.Load(configFile); err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+			if conf.Listeners.DNS != "" {
+				ip, port, _ = conf.Listeners.DNSAddress()
+			}
+			if conf.Listeners.HTTP != "" {
+				httpServer = conf.Listeners.HTTP
+			}
+			if conf.Listeners.GRPC != "" {
+				grpcEndpoint = conf.Listeners.GRPC
+			}
 		}
-		defer db.Disconnect()
 
-		log.Debug("Starting services")
-		go func() {
-			if err := api.Run(grpcEndpoint); err != nil {
-				panic(err.Error())
+		if err := applyConfig(c, conf); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		if err := setEncryptionKey(c); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		if c.Bool("migrate-dry-run") {
+			pending, err := db.DryRun(storage)
+			if err != nil {
+				log.Errorf("Migration dry run failed: %s", err.Error())
+				return err
+			}
+			if len(pending) == 0 {
+				fmt.Printf("Schema version %d is current\n", db.SchemaVersion())
 			}
-		}()
-		go func() {
-			if err := api.RunEndPoint(grpcEndpoint, httpServer); err != nil {
-				panic(err.Error())
+			for _, m := range pending {
+				fmt.Printf("Pending migration to version %d: %s\n", m.Version, m.Description)
 			}
-		}()
+			return nil
+		}
+
+		log.Debugf("Connecting to %s", storage)
+		store, err := db.Open(storage)
+		if err != nil {
+			return cli.NewExitError("Failed to open the database: "+err.Error(), 1)
+		}
+		defer store.Disconnect()
+
+		handler := ddns.NewHandler(store)
+
+		if auditLog := c.String("audit-log"); auditLog != "" {
+			if err := audit.Open(auditLog, c.Int("audit-max-size"), c.Int("audit-keep")); err != nil {
+				return err
+			}
+			defer audit.Close()
+		}
+
+		if dyndnsUsers := c.String("dyndns-users"); dyndnsUsers != "" {
+			if err := api.LoadDynDNSUsers(dyndnsUsers); err != nil {
+				return err
+			}
+		}
+
+		if tokens := c.String("tokens"); tokens != "" {
+			if err := api.LoadTokens(tokens); err != nil {
+				return err
+			}
+		}
+		api.EnableAuth(c.Bool("auth"))
+
+		if tlsCert := c.String("tls-cert"); tlsCert != "" {
+			if err := api.SetTLS(tlsCert, c.String("tls-key"), c.String("tls-ca")); err != nil {
+				return err
+			}
+		}
+
+		if clientCerts := c.String("client-certs"); clientCerts != "" {
+			if err := api.LoadClientCerts(clientCerts); err != nil {
+				return err
+			}
+		}
+
+		primary := c.String("primary")
+		if primary != "" {
+
+			writes := c.String("replica-writes")
+			if writes != "forward" && writes != "reject" {
+				return errors.New("Replica writes not supported (Use one of forward, reject): " + writes)
+			}
+			// the primary must authenticate the clients of the forwarded writes
+			if writes == "forward" && !c.Bool("auth") {
+				return errors.New("Replica writes forwarded to the primary require the authentication, set auth or replica-writes reject")
+			}
+
+			dialOption, err := dialOption(c, c.String("primary-ca"))
+			if err != nil {
+				return err
+			}
+
+			log.Debugf("Replicating from %s", primary)
+			replica, err := api.RunReplica(primary, store, c.String("primary-token"), writes == "forward", dialOption)
+			if err != nil {
+				return err
+			}
+			defer replica.Stop()
+
+			handler.SetReadOnly(true)
+		}
+
+		if clusterBind := c.String("cluster-bind"); clusterBind != "" {
+
+			if primary != "" {
+				return errors.New("A replica can not run in a cluster")
+			}
+
+			config := db.ClusterConfig{
+				ID:         c.String("cluster-id"),
+				Address:    clusterBind,
+				APIAddress: c.String("cluster-api"),
+				Dir:        c.String("cluster-dir"),
+				Bootstrap:  c.Bool("cluster-bootstrap"),
+			}
+			if config.ID == "" {
+				config.ID, _ = os.Hostname()
+			}
+			if config.APIAddress == "" {
+				config.APIAddress = grpcEndpoint
+			}
+
+			config.TLS, err = clusterTLS(c)
+			if err != nil {
+				return err
+			}
+
+			dialOption, err := dialOption(c, c.String("cluster-ca"))
+			if err != nil {
+				return err
+			}
+
+			log.Debugf("Starting cluster node %s on %s", config.ID, clusterBind)
+			cluster, err := store.StartCluster(config)
+			if err != nil {
+				return err
+			}
+			defer cluster.Close()
+
+			clusterAPI := api.RunCluster(cluster, c.String("cluster-join"), c.String("cluster-token"), dialOption)
+			defer clusterAPI.Stop()
+		}
+
+		var reload func()
+		if configFile != "" {
+			reload = reloader(c, configFile, conf.Listeners)
+			stopWatch := config.Watch(configFile, configWatchInterval, reload)
+			defer stopWatch()
+		}
+
+		// the servers are stopped on SIGINT or SIGTERM, or when one fails,
+		// then the deferred cluster, replica and database are closed
+		sup := supervisor.New(context.Background())
+		stopSignals := handleSignals(sup.Stop, reload)
+		defer stopSignals()
+
+		shutdownTimeout := time.Second * time.Duration(c.Int("shutdown-timeout"))
+
+		log.Debug("Starting services")
+		grpcAPI, err := api.Listen(grpcEndpoint, store)
+		if err != nil {
+			return startFailed(sup, shutdownTimeout, "gRPC API", err)
+		}
+		sup.Serve("gRPC API", grpcAPI)
+
+		httpAPI, err := api.ListenEndPoint(httpServer, store)
+		if err != nil {
+			return startFailed(sup, shutdownTimeout, "HTTP API", err)
+		}
+		sup.Serve("HTTP API", httpAPI)
 
 		if coreDNSEndpoint != "" {
-			go func() {
-				log.Debugf("Start CoreDNS handler %s", coreDNSEndpoint)
-				coreDNSGRPC(coreDNSEndpoint)
-			}()
+			log.Debugf("Start CoreDNS handler %s", coreDNSEndpoint)
+			coreDNS, err := coredns_grpc.Listen(coreDNSEndpoint, handler)
+			if err != nil {
+				return startFailed(sup, shutdownTimeout, "CoreDNS endpoint", err)
+			}
+			sup.Serve("CoreDNS endpoint", coreDNS)
 		} else {
+			// Start internal DNS server
+			dnsServer, err := ddns.Listen(ip, port, handler)
+			if err != nil {
+				return startFailed(sup, shutdownTimeout, "DNS server", err)
+			}
+			sup.Serve("DNS server", dnsServer)
+		}
+
+		db.SetHistoryRetention(c.Int("history-limit"), time.Hour*24*time.Duration(c.Int("history-retention")))
 
-			// Attach request handler func
-			log.Debug("Attaching DNS handler")
-			dns.HandleFunc(".", func(w dns.ResponseWriter, request *dns.Msg) {
-				response := ddns.HandleDNSRequest(request)
-				w.WriteMsg(response)
+		// the primary, or the cluster leader, removes the expired records and
+		// prunes the history of the other nodes
+		if primary == "" {
+			sup.Go("scheduler", func(ctx context.Context) error {
+				return scheduler(ctx, store, handler)
 			})
+		}
 
-			// Start internal DNS server
-			go ddns.Serve(ip, port)
+		if healthInterval := c.Int("health-interval"); healthInterval > 0 {
+			log.Debugf("Starting health checks every %ds", healthInterval)
+			sup.Go("health checks", func(ctx context.Context) error {
+				return health.Run(ctx, store, time.Second*time.Duration(healthInterval))
+			})
 		}
 
-		// scheduler()
-		ticker := scheduler()
-		defer ticker.Stop()
+		if snapshotDir := c.String("snapshot-dir"); snapshotDir != "" {
+			log.Debugf("Saving snapshots to %s every %dh", snapshotDir, c.Int("snapshot-interval"))
+			sup.Go("snapshots", func(ctx context.Context) error {
+				return snapshots(ctx, store, snapshotDir, time.Hour*time.Duration(c.Int("snapshot-interval")), c.Int("snapshot-keep"))
+			})
+		}
 
-		waitSignal()
+		log.Info("Started")
+		if err := sup.Wait(shutdownTimeout); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
 
 		return nil
 	}
 
-	app.Run(os.Args)
+	app.Commands = []cli.Command{
+		{
+			Name:      "backup",
+			Usage:     "Download a snapshot of the database from a running service",
+			ArgsUsage: "[output file]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "url",
+					Value: "http://127.0.0.1:5551",
+					Usage: "URL of the HTTP API",
+				},
+				cli.StringFlag{
+					Name:   "token",
+					Usage:  "API token with the admin scope",
+					EnvVar: "DDNS_TOKEN",
+				},
+				cli.StringFlag{
+					Name:  "ca",
+					Usage: "CA certificate file to verify the HTTPS API",
+				},
+			},
+			Action: backup,
+		},
+		{
+			Name:      "restore",
+			Usage:     "Replace the database with a snapshot, the service must be stopped",
+			ArgsUsage: "<snapshot file>",
+			Action:    restore,
+		},
+		{
+			Name:  "reencrypt",
+			Usage: "Encrypt the database with a new key, the service must be stopped",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "new-key-file",
+					Usage:  "File with the new 32 bytes key, in base64 or hex",
+					EnvVar: "NEW_ENCRYPTION_KEY_FILE",
+				},
+				cli.StringFlag{
+					Name:   "new-key",
+					Usage:  "New 32 bytes key, in base64 or hex",
+					EnvVar: "NEW_ENCRYPTION_KEY",
+				},
+			},
+			Action: reencrypt,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// storageURL return the storage selected by the global flags
+func storageURL(c *cli.Context) string {
+	if storage := c.GlobalString("storage"); storage != "" {
+		return storage
+	}
+	return "bolt://" + c.GlobalString("dbpath")
+}
+
+func backup(c *cli.Context) error {
+	if err := download(c); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+// download save the backup stream of the HTTP API to a file
+func download(c *cli.Context) error {
+
+	output := c.Args().First()
+	if output == "" {
+		output = "ddns-" + time.Now().Format("20060102150405") + ".db"
+	}
+
+	client := http.DefaultClient
+	if ca := c.String("ca"); ca != "" {
+		pool, err := loadCA(ca)
+		if err != nil {
+			return err
+		}
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(c.String("url"), "/")+"/v1/backup", nil)
+	if err != nil {
+		return err
+	}
+	if token := c.String("token"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Backup failed: %s %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// a partial download is never left at the output path
+	tmp := output + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, res.Body)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, output); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved backup of %d bytes to %s\n", n, output)
+	return nil
+}
+
+// loadCA load a CA certificate file
+func loadCA(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("No certificate found in " + path)
+	}
+	return pool, nil
 }
 
-func coreDNSGRPC(endpoint string) error {
-	lis, err := net.Listen("tcp", endpoint)
+// dialOption return the credentials to connect to the primary or the cluster
+// nodes, over TLS if the CA is set, with the API certificate as client certificate
+func dialOption(c *cli.Context, ca string) (grpc.DialOption, error) {
+
+	if ca == "" {
+		return grpc.WithInsecure(), nil
+	}
+
+	pool, err := loadCA(ca)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		return nil, err
+	}
+	config := &tls.Config{RootCAs: pool}
+
+	if certFile := c.String("tls-cert"); certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, c.String("tls-key"))
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
 	}
-	grpcServer := grpc.NewServer()
-	api.RegisterDnsServiceServer(grpcServer, &coredns_grpc.DnsServer{})
-	defer grpcServer.GracefulStop()
-	return grpcServer.Serve(lis)
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
 }
 
-func scheduler() *time.Ticker {
+// clusterTLS return the mutual TLS configuration of the Raft transport, nil
+// if the cluster CA is not set
+func clusterTLS(c *cli.Context) (*tls.Config, error) {
+
+	ca := c.String("cluster-ca")
+	if ca == "" {
+		return nil, nil
+	}
+
+	certFile := c.String("tls-cert")
+	if certFile == "" {
+		return nil, errors.New("The cluster CA requires the node certificate, set tls-cert and tls-key")
+	}
+
+	pool, err := loadCA(ca)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, c.String("tls-key"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// setEncryptionKey load the encryption key selected by the global flags
+func setEncryptionKey(c *cli.Context) error {
+	key, err := encryptionKey(c.GlobalString("encryption-key-file"), c.GlobalString("encryption-key"))
+	if err != nil {
+		return err
+	}
+	db.SetEncryptionKey(key)
+	return nil
+}
+
+// encryptionKey return the key of a file or a value, nil if both are empty
+func encryptionKey(file string, value string) ([]byte, error) {
+	if file != "" {
+		key, err := db.LoadEncryptionKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("Encryption key %s not valid: %s", file, err.Error())
+		}
+		return key, nil
+	}
+	if value != "" {
+		return db.ParseEncryptionKey(value)
+	}
+	return nil, nil
+}
+
+func reencrypt(c *cli.Context) error {
+
+	if err := setEncryptionKey(c); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	key, err := encryptionKey(c.String("new-key-file"), c.String("new-key"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	if key == nil {
+		return cli.NewExitError("New encryption key is missing, set it with --new-key-file", 1)
+	}
+
+	count, err := db.Reencrypt(storageURL(c), key)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Printf("Encrypted %d values, start the service with the new key\n", count)
+	return nil
+}
+
+func restore(c *cli.Context) error {
+
+	snapshot := c.Args().First()
+	if snapshot == "" {
+		return cli.NewExitError("Snapshot file is missing", 1)
+	}
+
+	if err := setEncryptionKey(c); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	previous, err := db.Restore(storageURL(c), snapshot)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	if previous != "" {
+		fmt.Printf("Restored %s, the previous database is saved as %s\n", snapshot, previous)
+	} else {
+		fmt.Printf("Restored %s\n", snapshot)
+	}
+	return nil
+}
+
+func scheduler(ctx context.Context, store *db.DB, handler *ddns.Handler) error {
 
 	ticker := time.NewTicker(time.Second * timerSeconds)
-	go func() {
-		for range ticker.C {
-			ddns.RemoveExpired()
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
-	}()
 
-	return ticker
+		if !store.IsLeader() {
+			continue
+		}
+		handler.RemoveExpired()
+		if time.Since(lastPrune) > historyPruneInterval {
+			if err := store.PruneHistory(); err != nil {
+				log.Errorf("Failed to prune the history: %s", err.Error())
+			}
+			lastPrune = time.Now()
+		}
+	}
 }
 
-func waitSignal() {
+func snapshots(ctx context.Context, store *db.DB, dir string, interval time.Duration, keep int) error {
 
-	sig := make(chan os.Signal)
-	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	quit := false
 	for {
 		select {
-		case s := <-sig:
-			log.Printf("Signal (%d) received, stopping", s)
-			quit = true
-			break
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
-		if quit {
-			break
+
+		if _, err := store.SaveSnapshot(dir, keep); err != nil {
+			log.Errorf("Failed to save the snapshot: %s", err.Error())
 		}
 	}
+}
+
+// applyConfig set the ACLs, zones, TSIG keys and upstream servers of the
+// configuration, the ACLs not set in the file are read from the flags
+func applyConfig(c *cli.Context, conf *config.Config) error {
+
+	allowQuery, err := ddns.ParseACL(aclSetting(conf.ACL.Query, c.String("allow-query")))
+	if err != nil {
+		return err
+	}
+
+	allowUpdate, err := ddns.ParseACL(aclSetting(conf.ACL.Update, c.String("allow-update")))
+	if err != nil {
+		return err
+	}
+
+	allowRecursion, err := ddns.ParseACL(aclSetting(conf.ACL.Recursion, c.String("allow-recursion")))
+	if err != nil {
+		return err
+	}
+
+	trustedProxies, err := api.ParseTrustedProxies(aclSetting(conf.ACL.TrustedProxies, c.String("trusted-proxies")))
+	if err != nil {
+		return err
+	}
+
+	if err := ddns.SetZones(conf.Zones); err != nil {
+		return err
+	}
+	if err := ddns.SetTSIGKeys(conf.Keys()); err != nil {
+		return err
+	}
+	if err := ddns.SetForwarders(conf.Forward.Upstreams, conf.ForwardTimeout()); err != nil {
+		return err
+	}
+
+	ddns.SetQueryACL(allowQuery)
+	ddns.SetUpdateACL(allowUpdate)
+	ddns.SetRecursionACL(allowRecursion)
+	api.SetTrustedProxies(trustedProxies)
+
+	return nil
+}
+
+// aclSetting return the ACL of the configuration file, or of the flag if not
+// set. An empty list in the file denies everybody
+func aclSetting(list []string, flag string) string {
+	if list == nil {
+		return flag
+	}
+	if len(list) == 0 {
+		return "none"
+	}
+	return strings.Join(list, ",")
+}
+
+// reloader return a function applying the configuration file again, the
+// listeners are bound at start and changed only by a restart
+func reloader(c *cli.Context, path string, listeners config.Listeners) func() {
+
+	var mu sync.Mutex
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		conf, err := config.Load(path)
+		if err != nil {
+			log.Errorf("Configuration not reloaded, keeping the current one: %s", err.Error())
+			return
+		}
+
+		if err := applyConfig(c, conf); err != nil {
+			log.Errorf("Configuration not reloaded, keeping the current one: %s", err.Error())
+			return
+		}
+
+		if conf.Listeners != listeners {
+			log.Warnf("The listeners of %s changed, restart to apply them", path)
+		}
+
+		log.Infof("Reloaded the configuration %s", path)
+	}
+}
+
+// handleSignals call stop on SIGINT or SIGTERM and reload on SIGHUP, if set.
+// A second stop signal exits without waiting. Returns a function to stop handling
+func handleSignals(stop func(), reload func()) func() {
+
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	if reload != nil {
+		signals = append(signals, syscall.SIGHUP)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+
+	done := make(chan struct{})
+	go func() {
+		stopping := false
+		for {
+			select {
+			case <-done:
+				return
+			case s := <-sig:
+				if s == syscall.SIGHUP {
+					log.Infof("Signal (%d) received, reloading the configuration", s)
+					reload()
+					continue
+				}
+				if stopping {
+					log.Warnf("Signal (%d) received again, exiting", s)
+					os.Exit(1)
+				}
+				log.Infof("Signal (%d) received, stopping", s)
+				stopping = true
+				stop()
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
 
+// startFailed stop the components already started, returns the exit error
+func startFailed(sup *supervisor.Supervisor, timeout time.Duration, name string, err error) error {
+	sup.Stop()
+	sup.Wait(timeout)
+	return cli.NewExitError("Failed to start the "+name+": "+err.Error(), 1)
 }
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ddns "github.com/muka/ddns/dns"
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config of the service, loaded from a YAML file. The settings not set in
+// the file keep the value of the flags
+type Config struct {
+	Listeners Listeners `yaml:"listeners"`
+	// Zones served from the records, the queries of other names are forwarded
+	Zones   []string  `yaml:"zones"`
+	TSIG    []TSIGKey `yaml:"tsig"`
+	ACL     ACL       `yaml:"acl"`
+	Forward Forward   `yaml:"forward"`
+}
+
+// Listeners addresses of the servers, applied on restart
+type Listeners struct {
+	// DNS address as ip:port
+	DNS  string `yaml:"dns"`
+	HTTP string `yaml:"http"`
+	GRPC string `yaml:"grpc"`
+}
+
+// TSIGKey shared secret of the clients sending dynamic updates
+type TSIGKey struct {
+	Name string `yaml:"name"`
+	// Algorithm one of hmac-sha1, hmac-sha224, hmac-sha256, hmac-sha384,
+	// hmac-sha512, default is hmac-sha256
+	Algorithm string `yaml:"algorithm"`
+	// Secret encoded in base64
+	Secret string `yaml:"secret"`
+}
+
+// ACL lists of networks, any, none or CIDR
+type ACL struct {
+	Query  []string `yaml:"query"`
+	Update []string `yaml:"update"`
+	// Recursion clients allowed to send the queries forwarded to the upstreams
+	Recursion      []string `yaml:"recursion"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// Forward upstream servers of the queries not served from the records
+type Forward struct {
+	// Upstreams as ip or ip:port, tried in order
+	Upstreams []string `yaml:"upstreams"`
+	// Timeout of a query, eg. 2s
+	Timeout string `yaml:"timeout"`
+}
+
+//Load read and validate a configuration file
+func Load(path string) (*Config, error) {
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+	default:
+		return nil, errors.New("Configuration format not supported (Use YAML, .yml or .yaml): " + path)
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(raw, config); err != nil {
+		return nil, fmt.Errorf("Invalid configuration %s: %s", path, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+
+	if problems := config.Validate(); len(problems) > 0 {
+		return nil, fmt.Errorf("Invalid configuration %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+
+	return config, nil
+}
+
+//Validate check the settings, returns a description of each invalid one
+func (c *Config) Validate() []string {
+
+	problems := make([]string, 0)
+	invalid := func(field string, err error) {
+		problems = append(problems, field+": "+err.Error())
+	}
+
+	if c.Listeners.DNS != "" {
+		if _, _, err := c.Listeners.DNSAddress(); err != nil {
+			invalid("listeners.dns", err)
+		}
+	}
+	if c.Listeners.HTTP != "" {
+		if _, _, err := net.SplitHostPort(c.Listeners.HTTP); err != nil {
+			invalid("listeners.http", err)
+		}
+	}
+	if c.Listeners.GRPC != "" {
+		if _, _, err := net.SplitHostPort(c.Listeners.GRPC); err != nil {
+			invalid("listeners.grpc", err)
+		}
+	}
+
+	for i, zone := range c.Zones {
+		if _, err := ddns.CanonicalName(zone); err != nil {
+			invalid(fmt.Sprintf("zones[%d]", i), err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, key := range c.TSIG {
+		parsed, err := ddns.ParseTSIGKey(ddns.TSIGKey(key))
+		if err != nil {
+			invalid(fmt.Sprintf("tsig[%d]", i), err)
+			continue
+		}
+		if names[parsed.Name] {
+			invalid(fmt.Sprintf("tsig[%d]", i), errors.New("Duplicated TSIG key "+parsed.Name))
+		}
+		names[parsed.Name] = true
+	}
+
+	acls := []struct {
+		field string
+		list  []string
+	}{
+		{"acl.query", c.ACL.Query},
+		{"acl.update", c.ACL.Update},
+		{"acl.recursion", c.ACL.Recursion},
+		{"acl.trusted_proxies", c.ACL.TrustedProxies},
+	}
+	for _, acl := range acls {
+		if _, err := ddns.ParseACL(strings.Join(acl.list, ",")); err != nil {
+			invalid(acl.field, err)
+		}
+	}
+	for _, proxy := range c.ACL.TrustedProxies {
+		if strings.TrimSpace(proxy) == "any" {
+			invalid("acl.trusted_proxies", errors.New("Trusting any proxy allows every client to forge its address, list the proxy networks"))
+		}
+	}
+
+	for i, upstream := range c.Forward.Upstreams {
+		if _, err := ddns.ParseUpstream(upstream); err != nil {
+			invalid(fmt.Sprintf("forward.upstreams[%d]", i), err)
+		}
+	}
+	if c.Forward.Timeout != "" {
+		if timeout, err := time.ParseDuration(c.Forward.Timeout); err != nil || timeout <= 0 {
+			invalid("forward.timeout", errors.New("Invalid duration, eg. 2s: "+c.Forward.Timeout))
+		}
+	}
+
+	return problems
+}
+
+//DNSAddress return the ip and port of the DNS listener
+func (l Listeners) DNSAddress() (string, int, error) {
+
+	host, port, err := net.SplitHostPort(l.DNS)
+	if err != nil {
+		return "", 0, err
+	}
+
+	number, err := net.LookupPort("udp", port)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return host, number, nil
+}
+
+//ForwardTimeout return the timeout of the forwarded queries, 0 if not set
+func (c *Config) ForwardTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(c.Forward.Timeout)
+	return timeout
+}
+
+//Keys return the TSIG keys
+func (c *Config) Keys() []ddns.TSIGKey {
+	keys := make([]ddns.TSIGKey, len(c.TSIG))
+	for i, key := range c.TSIG {
+		keys[i] = ddns.TSIGKey(key)
+	}
+	return keys
+}
+
+//Watch call reload when the file changes, checking every interval.
+// Returns a function to stop watching
+func Watch(path string, interval time.Duration, reload func()) func() {
+
+	stop := make(chan struct{})
+	last := modified(path)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				current := modified(path)
+				if current == last {
+					continue
+				}
+				last = current
+				log.Debugf("Configuration %s changed", path)
+				reload()
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+	}
+}
+
+// modified return the modification time and size of a file, to detect the
+// changes also when the file is replaced
+func modified(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+}
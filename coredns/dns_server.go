@@ -3,13 +3,24 @@ package coredns
 import (
 	"context"
 	"fmt"
+	"net"
 
 	"github.com/miekg/dns"
 	"github.com/muka/ddns/api"
 	ddns_dns "github.com/muka/ddns/dns"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
-type DnsServer struct{}
+// clientMetadataKeys are checked, in order, for the address of the DNS client
+// forwarded by the CoreDNS instance
+var clientMetadataKeys = []string{"x-forwarded-for", "x-real-ip"}
+
+// DnsServer answer the DNS packets forwarded by CoreDNS
+type DnsServer struct {
+	Handler *ddns_dns.Handler
+}
 
 func (d *DnsServer) Query(ctx context.Context, in *api.DnsPacket) (*api.DnsPacket, error) {
 
@@ -18,7 +29,7 @@ func (d *DnsServer) Query(ctx context.Context, in *api.DnsPacket) (*api.DnsPacke
 		return nil, fmt.Errorf("failed to unpack msg: %v", err)
 	}
 
-	response := ddns_dns.HandleDNSRequest(request)
+	response := d.Handler.HandleDNSRequest(request, clientIP(ctx))
 
 	out, err := response.Pack()
 	if err != nil {
@@ -27,3 +38,71 @@ func (d *DnsServer) Query(ctx context.Context, in *api.DnsPacket) (*api.DnsPacke
 
 	return &api.DnsPacket{Msg: out}, nil
 }
+
+// clientIP return the address of the client, from metadata when set by a
+// trusted proxy, or the gRPC peer
+func clientIP(ctx context.Context) net.IP {
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	remote := ddns_dns.ClientIP(p.Addr)
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range clientMetadataKeys {
+			if values := md.Get(key); len(values) > 0 {
+				return api.ForwardedIP(remote, values)
+			}
+		}
+	}
+
+	return remote
+}
+
+// Server serve the gRPC endpoint of the CoreDNS grpc plugin
+type Server struct {
+	listener net.Listener
+	server   *grpc.Server
+}
+
+// Listen bind the CoreDNS endpoint, serve it with Serve
+func Listen(endpoint string, handler *ddns_dns.Handler) (*Server, error) {
+
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	server := grpc.NewServer()
+	api.RegisterDnsServiceServer(server, &DnsServer{Handler: handler})
+
+	return &Server{listener: listener, server: server}, nil
+}
+
+// Serve the requests until shut down
+func (s *Server) Serve() error {
+	err := s.server.Serve(s.listener)
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stop accepting requests and wait the in-flight ones until the context is done
+func (s *Server) Shutdown(ctx context.Context) error {
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
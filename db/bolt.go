@@ -0,0 +1,170 @@
+package db
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// boltStore is the default Store, saved to a single file
+type boltStore struct {
+	db *bolt.DB
+	watchers
+}
+
+type boltTx struct {
+	tx     *bolt.Tx
+	events []Event
+}
+
+//NewBoltStore open a bolt database file
+func NewBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) View(fn func(tx StoreTx) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+func (s *boltStore) Update(fn func(tx StoreTx) error) error {
+	btx := &boltTx{}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		btx.tx = tx
+		return fn(btx)
+	})
+	if err == nil {
+		s.notify(btx.events)
+	}
+	return err
+}
+
+func (s *boltStore) Watch(bucket string, prefix string) (<-chan Event, func()) {
+	return s.add(bucket, prefix)
+}
+
+func (s *boltStore) Snapshot(w io.Writer) (int64, error) {
+	var n int64
+	err := s.db.View(func(tx *bolt.Tx) (err error) {
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func (t *boltTx) Get(bucket string, key string) []byte {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.Get([]byte(key))
+}
+
+func (t *boltTx) Put(bucket string, key string, value []byte) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	if err := b.Put([]byte(key), value); err != nil {
+		return err
+	}
+	t.events = append(t.events, Event{Bucket: bucket, Key: key, Value: append([]byte{}, value...)})
+	return nil
+}
+
+func (t *boltTx) Delete(bucket string, key string) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil || b.Get([]byte(key)) == nil {
+		return nil
+	}
+	if err := b.Delete([]byte(key)); err != nil {
+		return err
+	}
+	t.events = append(t.events, Event{Bucket: bucket, Key: key, Deleted: true})
+	return nil
+}
+
+func (t *boltTx) Scan(bucket string, prefix string, fn func(key string, value []byte) error) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	p := []byte(prefix)
+	c := b.Cursor()
+	for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+		// skip nested buckets
+		if v == nil {
+			continue
+		}
+		if err := fn(string(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactBolt rewrite a bolt file without its free pages, the database must be closed
+func compactBolt(path string) error {
+
+	src, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".compact"
+	dst, err := bolt.Open(tmp, 0600, nil)
+	if err != nil {
+		return err
+	}
+
+	err = src.View(func(stx *bolt.Tx) error {
+		return dst.Update(func(dtx *bolt.Tx) error {
+			return stx.ForEach(func(name []byte, b *bolt.Bucket) error {
+				nb, err := dtx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(b, nb)
+			})
+		})
+	})
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	src.Close()
+	return os.Rename(tmp, path)
+}
+
+// copyBucket copy the keys and the nested buckets of a bucket
+func copyBucket(src *bolt.Bucket, dst *bolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v == nil {
+			nb, err := dst.CreateBucket(k)
+			if err != nil {
+				return err
+			}
+			return copyBucket(src.Bucket(k), nb)
+		}
+		return dst.Put(k, v)
+	})
+}
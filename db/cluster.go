@@ -0,0 +1,630 @@
+package db
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb"
+	log "github.com/sirupsen/logrus"
+)
+
+// clusterBucket store the API address of the cluster nodes, by ID
+const clusterBucket = "cluster"
+
+// clusterTimeout is the max time to commit a change to the Raft log
+const clusterTimeout = 10 * time.Second
+
+// ClusterConfig configure the node of a Raft cluster
+type ClusterConfig struct {
+	// ID unique name of the node
+	ID string
+	// Address Raft host:port of the node, reachable by the other nodes
+	Address string
+	// APIAddress gRPC address of the node, where the writes are forwarded
+	// when it is the leader
+	APIAddress string
+	// Dir directory of the Raft log and snapshots
+	Dir string
+	// Bootstrap start a new cluster with this node, seeded with the data of the store
+	Bootstrap bool
+	// TLS of the Raft transport, with the node certificate and the CA of the
+	// cluster in RootCAs, verifying the certificates of both sides. Without
+	// TLS the Raft traffic is not authenticated and Address must be loopback
+	TLS *tls.Config
+}
+
+// ClusterNode is a member of the cluster
+type ClusterNode struct {
+	ID         string
+	Address    string
+	APIAddress string
+	Leader     bool
+	Voter      bool
+}
+
+// Cluster replicate the writes of a DB to its nodes through a Raft log
+type Cluster struct {
+	config    ClusterConfig
+	raft      *raft.Raft
+	logs      *raftboltdb.BoltStore
+	transport *raft.NetworkTransport
+	store     *clusterStore
+	done      chan struct{}
+}
+
+// clusterCommand is a log entry, the changes of a transaction
+type clusterCommand struct {
+	// Load replace the whole store with the events, to seed the cluster
+	Load   bool `json:",omitempty"`
+	Events []Event
+}
+
+// clusterAEAD return the cipher of the Raft log entries and snapshots, derived
+// from the master key shared by the nodes, nil if the encryption is disabled
+func clusterAEAD() (cipher.AEAD, error) {
+	if encryptionKey == nil {
+		return nil, nil
+	}
+	mac := hmac.New(sha256.New, encryptionKey)
+	mac.Write([]byte("ddns cluster log"))
+	return newAEAD(mac.Sum(nil))
+}
+
+// sealLog encrypt a log entry or a snapshot, if the encryption is enabled
+func sealLog(data []byte) ([]byte, error) {
+	aead, err := clusterAEAD()
+	if err != nil || aead == nil {
+		return data, err
+	}
+	return seal(aead, clusterBucket, "log", data)
+}
+
+// unsealLog decrypt a log entry or a snapshot, the ones written before the
+// encryption was enabled are returned as is
+func unsealLog(data []byte) ([]byte, error) {
+	if !isEncrypted(data) {
+		return data, nil
+	}
+	aead, err := clusterAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if aead == nil {
+		return nil, ErrEncryptionKeyMissing
+	}
+	return unseal(aead, clusterBucket, "log", data)
+}
+
+//StartCluster join the DB to a Raft cluster. The writes are committed to the
+// log by the leader and applied to the store by every node, the reads are
+// served by the local store
+func (d *DB) StartCluster(config ClusterConfig) (*Cluster, error) {
+
+	if config.TLS == nil && !isLoopback(config.Address) {
+		return nil, errors.New("The Raft transport of " + config.Address + " is not authenticated, set the cluster CA and the node certificate to bind a non loopback address")
+	}
+
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	// the Raft logs are written by logrus, which adds the time
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:        "raft",
+		Level:       hclog.Info,
+		Output:      log.StandardLogger().Writer(),
+		DisableTime: true,
+	})
+	if log.GetLevel() >= log.DebugLevel {
+		logger.SetLevel(hclog.Debug)
+	}
+
+	conf := raft.DefaultConfig()
+	conf.LocalID = raft.ServerID(config.ID)
+	conf.Logger = logger
+
+	logs, err := raftboltdb.NewBoltStore(filepath.Join(config.Dir, "raft.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := raft.NewFileSnapshotStoreWithLogger(config.Dir, 2, logger)
+	if err != nil {
+		logs.Close()
+		return nil, err
+	}
+
+	var transport *raft.NetworkTransport
+	if config.TLS != nil {
+		stream, err := newTLSStreamLayer(config.Address, config.TLS)
+		if err != nil {
+			logs.Close()
+			return nil, err
+		}
+		transport = raft.NewNetworkTransportWithLogger(stream, 3, clusterTimeout, logger)
+	} else {
+		transport, err = raft.NewTCPTransportWithLogger(config.Address, nil, 3, clusterTimeout, logger)
+		if err != nil {
+			logs.Close()
+			return nil, err
+		}
+	}
+
+	existing, err := raft.HasExistingState(logs, logs, snapshots)
+	if err != nil {
+		transport.Close()
+		logs.Close()
+		return nil, err
+	}
+
+	local := New(d.store)
+	r, err := raft.NewRaft(conf, &clusterFSM{local: local}, logs, logs, snapshots, transport)
+	if err != nil {
+		transport.Close()
+		logs.Close()
+		return nil, err
+	}
+
+	c := &Cluster{
+		config:    config,
+		raft:      r,
+		logs:      logs,
+		transport: transport,
+		store:     &clusterStore{local: d.store, raft: r},
+		done:      make(chan struct{}),
+	}
+
+	seed := false
+	if config.Bootstrap && !existing {
+		log.Infof("Bootstrapping the cluster with node %s", config.ID)
+		servers := []raft.Server{{ID: conf.LocalID, Address: transport.LocalAddr()}}
+		if err := r.BootstrapCluster(raft.Configuration{Servers: servers}).Error(); err != nil {
+			c.Close()
+			return nil, err
+		}
+		seed = true
+	}
+
+	d.store = c.store
+	go c.run(seed)
+
+	return c, nil
+}
+
+// run follow the leadership changes, a new leader register its API address
+func (c *Cluster) run(seed bool) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case leader := <-c.raft.LeaderCh():
+			if !leader {
+				log.Infof("Node %s lost the cluster leadership", c.config.ID)
+				continue
+			}
+
+			log.Infof("Node %s is the cluster leader", c.config.ID)
+
+			if seed {
+				if err := c.seed(); err != nil {
+					log.Errorf("Failed to seed the cluster: %s", err.Error())
+					continue
+				}
+				seed = false
+			}
+
+			if err := c.register(c.config.ID, c.config.APIAddress); err != nil {
+				log.Errorf("Failed to register the node %s: %s", c.config.ID, err.Error())
+			}
+		}
+	}
+}
+
+// seed commit the data of the bootstrap node to the log, loaded by the nodes
+// joining the cluster
+func (c *Cluster) seed() error {
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+
+	dump := make([]Event, 0)
+	err := New(c.store.local).Dump(func(e Event) error {
+		dump = append(dump, e)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Seeding the cluster with %d keys", len(dump))
+	return c.store.apply(clusterCommand{Load: true, Events: dump})
+}
+
+// register save the API address of a node
+func (c *Cluster) register(id string, apiAddress string) error {
+	return c.store.Update(func(tx StoreTx) error {
+		if string(tx.Get(clusterBucket, id)) == apiAddress {
+			return nil
+		}
+		return tx.Put(clusterBucket, id, []byte(apiAddress))
+	})
+}
+
+//Config return the configuration of the node
+func (c *Cluster) Config() ClusterConfig {
+	return c.config
+}
+
+//IsLeader check if the node is the cluster leader
+func (c *Cluster) IsLeader() bool {
+	return c.raft.State() == raft.Leader
+}
+
+//Leader return the current leader, false if there is no leader
+func (c *Cluster) Leader() (ClusterNode, bool) {
+
+	address, id := c.raft.LeaderWithID()
+	if id == "" {
+		return ClusterNode{}, false
+	}
+
+	return ClusterNode{
+		ID:         string(id),
+		Address:    string(address),
+		APIAddress: c.apiAddress(string(id)),
+		Leader:     true,
+		Voter:      true,
+	}, true
+}
+
+// apiAddress return the API address registered by a node
+func (c *Cluster) apiAddress(id string) (address string) {
+	c.store.local.View(func(tx StoreTx) error {
+		address = string(tx.Get(clusterBucket, id))
+		return nil
+	})
+	return address
+}
+
+//Nodes return the members of the cluster
+func (c *Cluster) Nodes() ([]ClusterNode, error) {
+
+	future := c.raft.GetConfiguration()
+	if err := future.Error(); err != nil {
+		return nil, err
+	}
+
+	_, leader := c.raft.LeaderWithID()
+
+	nodes := make([]ClusterNode, 0)
+	for _, server := range future.Configuration().Servers {
+		nodes = append(nodes, ClusterNode{
+			ID:         string(server.ID),
+			Address:    string(server.Address),
+			APIAddress: c.apiAddress(string(server.ID)),
+			Leader:     server.ID == leader,
+			Voter:      server.Suffrage == raft.Voter,
+		})
+	}
+
+	return nodes, nil
+}
+
+//Join add a voting node to the cluster, or update its addresses. Only the leader
+// can change the members
+func (c *Cluster) Join(node ClusterNode) error {
+
+	if !c.IsLeader() {
+		return ErrNotLeader
+	}
+
+	log.Infof("Adding node %s at %s to the cluster", node.ID, node.Address)
+	err := c.raft.AddVoter(raft.ServerID(node.ID), raft.ServerAddress(node.Address), 0, clusterTimeout).Error()
+	if err != nil {
+		return err
+	}
+
+	return c.register(node.ID, node.APIAddress)
+}
+
+//Leave remove a node from the cluster. Only the leader can change the members
+func (c *Cluster) Leave(id string) error {
+
+	if !c.IsLeader() {
+		return ErrNotLeader
+	}
+
+	log.Infof("Removing node %s from the cluster", id)
+
+	// the leader steps down once removed, its address is deleted first
+	err := c.store.Update(func(tx StoreTx) error {
+		return tx.Delete(clusterBucket, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.raft.RemoveServer(raft.ServerID(id), 0, clusterTimeout).Error()
+}
+
+//Close stop the node, the store is left open
+func (c *Cluster) Close() error {
+
+	select {
+	case <-c.done:
+		return nil
+	default:
+		close(c.done)
+	}
+
+	err := c.raft.Shutdown().Error()
+	c.transport.Close()
+	if e := c.logs.Close(); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+//IsLeader return false if the DB is a follower of a cluster, which can not
+// commit writes
+func (d *DB) IsLeader() bool {
+	if s, ok := d.store.(*clusterStore); ok {
+		return s.raft.State() == raft.Leader
+	}
+	return true
+}
+
+// clusterStore commit the writes to the Raft log, and read from the local store
+type clusterStore struct {
+	local Store
+	raft  *raft.Raft
+	// mu serialize the transactions of the leader, each one reads the
+	// changes applied by the previous one
+	mu sync.Mutex
+}
+
+func (s *clusterStore) View(fn func(tx StoreTx) error) error {
+	return s.local.View(fn)
+}
+
+func (s *clusterStore) Update(fn func(tx StoreTx) error) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.raft.State() != raft.Leader {
+		return ErrNotLeader
+	}
+
+	var events []Event
+	err := s.local.View(func(tx StoreTx) error {
+		ltx := &logTx{
+			tx:      tx,
+			changes: make(map[string]map[string][]byte),
+			deleted: make(map[string]map[string]bool),
+		}
+		if err := fn(ltx); err != nil {
+			return err
+		}
+		events = ltx.events
+		return nil
+	})
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	return s.apply(clusterCommand{Events: events})
+}
+
+// apply commit a command to the log, it returns once applied to the local store
+func (s *clusterStore) apply(cmd clusterCommand) error {
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+
+	// the log keeps the values of the changes, sealed like the store
+	data, err = sealLog(data)
+	if err != nil {
+		return err
+	}
+
+	future := s.raft.Apply(data, clusterTimeout)
+	if err := future.Error(); err != nil {
+		if err == raft.ErrNotLeader {
+			return ErrNotLeader
+		}
+		return err
+	}
+
+	if err, ok := future.Response().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+func (s *clusterStore) Watch(bucket string, prefix string) (<-chan Event, func()) {
+	return s.local.Watch(bucket, prefix)
+}
+
+func (s *clusterStore) Snapshot(w io.Writer) (int64, error) {
+	snapshotter, ok := s.local.(Snapshotter)
+	if !ok {
+		return 0, ErrSnapshotUnsupported
+	}
+	return snapshotter.Snapshot(w)
+}
+
+func (s *clusterStore) Close() error {
+	return s.local.Close()
+}
+
+// logTx read from the local store and record the changes to commit to the log
+type logTx struct {
+	tx      StoreTx
+	changes map[string]map[string][]byte
+	deleted map[string]map[string]bool
+	events  []Event
+}
+
+func (t *logTx) Get(bucket string, key string) []byte {
+	if value, ok := t.changes[bucket][key]; ok {
+		return value
+	}
+	if t.deleted[bucket][key] {
+		return nil
+	}
+	return t.tx.Get(bucket, key)
+}
+
+func (t *logTx) Put(bucket string, key string, value []byte) error {
+	if t.changes[bucket] == nil {
+		t.changes[bucket] = make(map[string][]byte)
+	}
+	value = append([]byte{}, value...)
+	t.changes[bucket][key] = value
+	delete(t.deleted[bucket], key)
+	t.events = append(t.events, Event{Bucket: bucket, Key: key, Value: value})
+	return nil
+}
+
+func (t *logTx) Delete(bucket string, key string) error {
+	if t.Get(bucket, key) == nil {
+		return nil
+	}
+	delete(t.changes[bucket], key)
+	if t.deleted[bucket] == nil {
+		t.deleted[bucket] = make(map[string]bool)
+	}
+	t.deleted[bucket][key] = true
+	t.events = append(t.events, Event{Bucket: bucket, Key: key, Deleted: true})
+	return nil
+}
+
+func (t *logTx) Scan(bucket string, prefix string, fn func(key string, value []byte) error) error {
+
+	keys := make([]string, 0)
+	err := t.tx.Scan(bucket, prefix, func(key string, value []byte) error {
+		if _, ok := t.changes[bucket][key]; !ok && !t.deleted[bucket][key] {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for key := range t.changes[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := fn(key, t.Get(bucket, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clusterFSM apply the committed log entries to the local store
+type clusterFSM struct {
+	local *DB
+}
+
+func (f *clusterFSM) Apply(l *raft.Log) interface{} {
+
+	data, err := unsealLog(l.Data)
+	if err != nil {
+		log.Errorf("Failed to decrypt the cluster log entry %d: %s", l.Index, err.Error())
+		return err
+	}
+
+	cmd := clusterCommand{}
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		log.Errorf("Failed to decode the cluster log entry %d: %s", l.Index, err.Error())
+		return err
+	}
+
+	if cmd.Load {
+		err = f.local.Load(cmd.Events)
+	} else {
+		err = f.local.Apply(cmd.Events...)
+	}
+	if err != nil {
+		log.Errorf("Failed to apply the cluster log entry %d: %s", l.Index, err.Error())
+	}
+
+	return err
+}
+
+// Snapshot copy the store, the log entries applied to it are then discarded
+func (f *clusterFSM) Snapshot() (raft.FSMSnapshot, error) {
+	dump := make([]Event, 0)
+	err := f.local.Dump(func(e Event) error {
+		dump = append(dump, e)
+		return nil
+	})
+	return &clusterSnapshot{dump: dump}, err
+}
+
+// Restore replace the store with a snapshot of the leader
+func (f *clusterFSM) Restore(r io.ReadCloser) error {
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	data, err = unsealLog(data)
+	if err != nil {
+		return err
+	}
+
+	dump := make([]Event, 0)
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return err
+	}
+
+	log.Infof("Restoring a cluster snapshot of %d keys", len(dump))
+	return f.local.Load(dump)
+}
+
+type clusterSnapshot struct {
+	dump []Event
+}
+
+func (s *clusterSnapshot) Persist(sink raft.SnapshotSink) error {
+	data, err := json.Marshal(s.dump)
+	if err == nil {
+		data, err = sealLog(data)
+	}
+	if err == nil {
+		_, err = sink.Write(data)
+	}
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *clusterSnapshot) Release() {}
@@ -3,23 +3,51 @@ package db
 import (
 	"encoding/json"
 	"errors"
-	"time"
+	"strings"
+	"sync"
 
 	"github.com/rs/xid"
 	log "github.com/sirupsen/logrus"
-
-	"github.com/boltdb/bolt"
 )
 
-var bdb *bolt.DB
-
 const rrBucket = "rr"
+const leaseBucket = "leases"
+const tokenBucket = "tokens"
 
-// Record store a DNS record with metadata
+// Record store a DNS record set with metadata
 type Record struct {
+	// RR first value of the set, kept for compatibility
 	RR      string
 	Expires int64
 	ID      string
+	// Values list of records sharing the same name and type
+	Values []Value `json:",omitempty"`
+	// Policy answer ordering policy of the set
+	Policy string `json:",omitempty"`
+	// Check health check of the values of the set
+	Check *HealthCheck `json:",omitempty"`
+	// LeaseID lease renewing the expiration of the set
+	LeaseID string `json:",omitempty"`
+}
+
+// HealthCheck store the health check configuration of a record set
+type HealthCheck struct {
+	// Type one of tcp, http
+	Type string
+	Port int
+	// Path and Status of the HTTP request
+	Path   string `json:",omitempty"`
+	Status int    `json:",omitempty"`
+	// Timeout in seconds
+	Timeout int `json:",omitempty"`
+	// Threshold of consecutive failures before marking a value as unhealthy
+	Threshold int `json:",omitempty"`
+}
+
+// Value store a single DNS record of a set
+type Value struct {
+	RR     string
+	Weight int `json:",omitempty"`
 }
 
 func genid() string {
@@ -32,119 +60,145 @@ func NewRecord(rr string, expires int64) Record {
 		ID:      genid(),
 		Expires: expires,
 		RR:      rr,
+		Values:  []Value{{RR: rr}},
 	}
 }
 
-//Connect create a bucket
-func Connect(dbPath string) error {
-	db, err := bolt.Open(dbPath, 0600, &bolt.Options{
-		Timeout: 10 * time.Second,
-	})
-	if err != nil {
-		return err
+//GetValues return the records of the set
+func (r Record) GetValues() []Value {
+	if len(r.Values) == 0 && r.RR != "" {
+		return []Value{{RR: r.RR}}
 	}
+	return r.Values
+}
+
+// DB store records, leases, tokens and history in a Store
+type DB struct {
+	store Store
 
-	bdb = db
-	// Create dns bucket if doesn't exist
-	createBucket(rrBucket)
+	feedOnce sync.Once
+	feed     *Feed
+}
+
+//New create a DB on a store
+func New(store Store) *DB {
+	return &DB{store: store}
+}
 
-	return nil
+//Connect open the default bolt database
+func Connect(dbPath string) (*DB, error) {
+	return Open("bolt://" + dbPath)
 }
 
-//Disconnect create a bucket
-func Disconnect() error {
-	if bdb == nil {
-		return nil
+//Open open the store selected by an URL, one of bolt://path, sqlite://path or memory://
+// and migrate it to the current schema version. The values are encrypted if
+// an encryption key is set
+func Open(storage string) (*DB, error) {
+
+	store, path, err := openStore(storage)
+	if err != nil {
+		return nil, err
 	}
-	return bdb.Close()
-}
-
-//createBucket create bucket if not exists
-func createBucket(bucket string) error {
-	return bdb.Update(func(tx *bolt.Tx) error {
-		_, err1 := tx.CreateBucketIfNotExists([]byte(bucket))
-		if err1 != nil {
-			log.Errorf("Failed to create bucket: %s", err1.Error())
-			return err1
-		}
-		return nil
-	})
+
+	encrypted, err := encrypt(store, true)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	store = encrypted
+
+	if _, err := migrate(store, path, false); err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return New(store), nil
 }
 
-//DeleteRecord create a bucket
-func DeleteRecord(key string) (err error) {
-	err = bdb.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(rrBucket))
-		err1 := b.Delete([]byte(key))
-
-		if err1 != nil {
-			e := errors.New("Delete record failed for domain:  " + key)
-			log.Println(e.Error())
-			return e
-		}
-
-		log.Debugf("Removed %s", key)
-		return nil
-	})
+//DryRun return the migrations pending on a store. They are applied and rolled
+// back, to check they succeed, leaving the store unchanged
+func DryRun(storage string) ([]Migration, error) {
+
+	store, path, err := openStore(storage)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	encrypted, err := encrypt(store, false)
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	return migrate(encrypted, path, true)
 }
 
-//StoreRecord save a new record
-func StoreRecord(key string, record Record) error {
-	return bdb.Update(func(tx *bolt.Tx) error {
+// openStore open the store selected by an URL, returns the store and its path
+func openStore(storage string) (Store, string, error) {
 
-		b := tx.Bucket([]byte(rrBucket))
+	parts := strings.SplitN(storage, "://", 2)
+	if len(parts) != 2 {
+		return nil, "", errors.New("Storage URL not valid: " + storage)
+	}
 
-		record.ID = genid()
-		log.Debugf("Set ID %s", record.ID)
+	var (
+		store Store
+		err   error
+	)
+
+	switch parts[0] {
+	case "bolt":
+		store, err = NewBoltStore(parts[1])
+	case "sqlite":
+		store, err = NewSQLiteStore(parts[1])
+	case "memory":
+		store = NewMemoryStore()
+	default:
+		return nil, "", errors.New("Storage not supported (Use one of bolt, sqlite, memory): " + parts[0])
+	}
 
-		val, err := json.Marshal(record)
-		if err != nil {
-			return err
-		}
+	return store, parts[1], err
+}
 
-		err1 := b.Put([]byte(key), val)
-		if err1 != nil {
-			return err1
-		}
+//Disconnect close the store
+func (d *DB) Disconnect() error {
+	return d.store.Close()
+}
 
-		return nil
+//Store return the underlying store
+func (d *DB) Store() Store {
+	return d.store
+}
+
+//DeleteRecord create a bucket
+func (d *DB) DeleteRecord(origin Origin, key string) error {
+	return d.Update(origin, func(tx *Tx) error {
+		return tx.DeleteRecord(key)
 	})
 }
 
-//GetRecord return a stored record for a domain
-func GetRecord(key string) (r Record, err error) {
-	err = bdb.View(func(tx *bolt.Tx) error {
-
-		b := tx.Bucket([]byte(rrBucket))
-		raw := b.Get([]byte(key))
-
-		if len(raw) == 0 {
-			e := errors.New("Record not found, key:  " + key)
-			log.Println(e.Error())
-			return e
-		}
-
-		e := json.Unmarshal(raw, &r)
-		if e != nil {
-			log.Errorf("Record unmarshalling failed: %s", e.Error())
-			return e
-		}
-
-		log.Debugf("Record found %s", r.RR)
-		return nil
+//StoreRecord save a new record
+func (d *DB) StoreRecord(origin Origin, key string, record Record) error {
+	return d.Update(origin, func(tx *Tx) error {
+		_, err := tx.StoreRecord(key, record)
+		return err
 	})
+}
 
+//GetRecord return a stored record for a domain
+func (d *DB) GetRecord(key string) (r Record, err error) {
+	err = d.View(func(tx *Tx) error {
+		r, err = tx.GetRecord(key)
+		return err
+	})
 	return r, err
 }
 
-//GetExpiredRecords return a list of records in the database
-func GetExpiredRecords() ([]string, error) {
-	list := make([]string, 0)
-	err := bdb.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(rrBucket))
-		err1 := b.ForEach(func(k, v []byte) error {
+//GetRecords return all the stored records
+func (d *DB) GetRecords() (map[string]Record, error) {
+	list := make(map[string]Record)
+	err := d.store.View(func(tx StoreTx) error {
+		return tx.Scan(rrBucket, "", func(k string, v []byte) error {
 
 			r := Record{}
 			e := json.Unmarshal(v, &r)
@@ -153,21 +207,15 @@ func GetExpiredRecords() ([]string, error) {
 				return e
 			}
 
-			if r.Expires > 0 && r.Expires < time.Now().Unix() {
-				log.Debugf("Drop %s", string(k))
-				list = append(list, string(k))
-			}
-
+			list[k] = r
 			return nil
 		})
-
-		return err1
 	})
 
 	if err != nil {
-		log.Errorf("Failed filter: %s", err.Error())
+		log.Errorf("Failed to list records: %s", err.Error())
 		return nil, err
 	}
 
-	return list, err
+	return list, nil
 }
@@ -0,0 +1,443 @@
+package db
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// dataKeyName is the data key of the store in the meta bucket, encrypted
+// with the master key
+const dataKeyName = "data_key"
+
+// encryptedMagic prefix the encrypted values, followed by the nonce and
+// the sealed value
+var encryptedMagic = []byte("\x00DE1")
+
+// encryptionKey is the master key, the values are not encrypted if nil
+var encryptionKey []byte
+
+var (
+	// ErrEncryptionKeyMissing is returned when opening an encrypted store without the key
+	ErrEncryptionKeyMissing = errors.New("The database is encrypted, the encryption key is missing")
+	// ErrEncryptionKeyWrong is returned when the key can not decrypt the store
+	ErrEncryptionKeyWrong = errors.New("The encryption key is wrong, it can not decrypt the database")
+)
+
+//SetEncryptionKey set the master key of the stores, the values are encrypted
+// with a data key of the store, encrypted with the master key
+func SetEncryptionKey(key []byte) {
+	encryptionKey = key
+}
+
+//ParseEncryptionKey decode a 32 bytes key, encoded in base64 or hex
+func ParseEncryptionKey(value string) ([]byte, error) {
+
+	value = strings.TrimSpace(value)
+
+	key, err := hex.DecodeString(value)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(value)
+	}
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("Encryption key must be 32 bytes, encoded in base64 or hex")
+	}
+
+	return key, nil
+}
+
+//LoadEncryptionKey read a key file, see ParseEncryptionKey
+func LoadEncryptionKey(path string) ([]byte, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEncryptionKey(string(raw))
+}
+
+// newAEAD return an AES-GCM cipher
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypt a value, bound to its bucket and key
+func seal(aead cipher.AEAD, bucket string, key string, value []byte) ([]byte, error) {
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(append([]byte{}, encryptedMagic...), nonce...)
+	return aead.Seal(out, nonce, value, []byte(bucket+"/"+key)), nil
+}
+
+// unseal decrypt a value, a value not encrypted is refused
+func unseal(aead cipher.AEAD, bucket string, key string, value []byte) ([]byte, error) {
+
+	if !isEncrypted(value) {
+		return nil, errors.New("Value of " + key + " is not encrypted, run reencrypt to encrypt the database")
+	}
+
+	value = value[len(encryptedMagic):]
+	if len(value) < aead.NonceSize() {
+		return nil, errors.New("Encrypted value of " + key + " is truncated")
+	}
+
+	nonce := value[:aead.NonceSize()]
+	plain, err := aead.Open(nil, nonce, value[aead.NonceSize():], []byte(bucket+"/"+key))
+	if err != nil {
+		return nil, errors.New("Failed to decrypt the value of " + key + ": " + err.Error())
+	}
+
+	return plain, nil
+}
+
+// isEncrypted check if a value is encrypted
+func isEncrypted(value []byte) bool {
+	return bytes.HasPrefix(value, encryptedMagic)
+}
+
+// newDataKey return a random data key, and the key encrypted with a master key
+func newDataKey(master []byte) (cipher.AEAD, []byte, error) {
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+
+	masterAEAD, err := newAEAD(master)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := seal(masterAEAD, metaBucket, dataKeyName, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := newAEAD(key)
+	return aead, wrapped, err
+}
+
+// dataKey return the data key of a store, nil if the store is not encrypted
+func dataKey(tx StoreTx, master []byte) (cipher.AEAD, error) {
+
+	wrapped := tx.Get(metaBucket, dataKeyName)
+	if wrapped == nil {
+		return nil, nil
+	}
+	if master == nil {
+		return nil, ErrEncryptionKeyMissing
+	}
+
+	masterAEAD, err := newAEAD(master)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := unseal(masterAEAD, metaBucket, dataKeyName, wrapped)
+	if err != nil {
+		return nil, ErrEncryptionKeyWrong
+	}
+
+	return newAEAD(key)
+}
+
+// encrypt wrap a store to encrypt its values with the master key. If create
+// is set, a data key is added to the stores not encrypted yet
+func encrypt(store Store, create bool) (Store, error) {
+
+	var aead cipher.AEAD
+	err := store.View(func(tx StoreTx) (err error) {
+		aead, err = dataKey(tx, encryptionKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if aead == nil {
+		if encryptionKey == nil || !create {
+			return store, nil
+		}
+
+		var wrapped []byte
+		aead, wrapped, err = newDataKey(encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+
+		// the existing values are encrypted with the data key
+		count := 0
+		err = store.Update(func(tx StoreTx) (err error) {
+			if count, err = sealValues(tx, nil, aead); err != nil {
+				return err
+			}
+			return tx.Put(metaBucket, dataKeyName, wrapped)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		log.Infof("Encryption enabled, encrypted %d values", count)
+	}
+
+	return &encryptedStore{store: store, aead: aead}, nil
+}
+
+//Reencrypt encrypt all the values of a store with a new data key, encrypted
+// with a new master key. The store is decrypted with the current master key,
+// the service must be stopped. Returns the number of values encrypted
+func Reencrypt(storage string, master []byte) (int, error) {
+
+	store, _, err := openStore(storage)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := reencrypt(store, master)
+	store.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	// the free pages of the file keep the values as they were before
+	return count, compact(storage)
+}
+
+// compact rewrite the file of a store
+func compact(storage string) error {
+	parts := strings.SplitN(storage, "://", 2)
+	switch parts[0] {
+	case "bolt":
+		return compactBolt(parts[1])
+	case "sqlite":
+		return vacuumSQLite(parts[1])
+	}
+	return nil
+}
+
+// reencrypt encrypt the values of a store in a single transaction
+func reencrypt(store Store, master []byte) (int, error) {
+
+	count := 0
+	err := store.Update(func(tx StoreTx) error {
+
+		current, err := dataKey(tx, encryptionKey)
+		if err != nil {
+			return err
+		}
+
+		aead, wrapped, err := newDataKey(master)
+		if err != nil {
+			return err
+		}
+
+		if count, err = sealValues(tx, current, aead); err != nil {
+			return err
+		}
+
+		return tx.Put(metaBucket, dataKeyName, wrapped)
+	})
+
+	return count, err
+}
+
+// sealValues encrypt the values of a store with a data key. The values are
+// decrypted with the current data key, nil if not encrypted yet, the values
+// not encrypted are sealed as they are. Returns the number of values encrypted
+func sealValues(tx StoreTx, current cipher.AEAD, aead cipher.AEAD) (int, error) {
+
+	count := 0
+	for _, bucket := range buckets {
+		if bucket == metaBucket {
+			continue
+		}
+
+		keys := make([]string, 0)
+		values := make([][]byte, 0)
+		err := tx.Scan(bucket, "", func(k string, v []byte) error {
+			if isEncrypted(v) {
+				if current == nil {
+					return ErrEncryptionKeyWrong
+				}
+				plain, err := unseal(current, bucket, k, v)
+				if err != nil {
+					return err
+				}
+				v = plain
+			}
+			keys = append(keys, k)
+			values = append(values, append([]byte{}, v...))
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for i, k := range keys {
+			sealed, err := seal(aead, bucket, k, values[i])
+			if err != nil {
+				return 0, err
+			}
+			if err := tx.Put(bucket, k, sealed); err != nil {
+				return 0, err
+			}
+		}
+		count += len(keys)
+	}
+
+	return count, nil
+}
+
+// encryptedStore encrypt the values of a store, except the meta bucket.
+// The keys are not encrypted, to be scanned by prefix
+type encryptedStore struct {
+	store Store
+	aead  cipher.AEAD
+}
+
+// encryptedTx decrypt the values read by a transaction. Get has no error
+// result, its first decryption failure is returned by the transaction
+type encryptedTx struct {
+	tx   StoreTx
+	aead cipher.AEAD
+	err  error
+}
+
+func (t *encryptedTx) run(fn func(tx StoreTx) error) error {
+	if err := fn(t); err != nil {
+		return err
+	}
+	return t.err
+}
+
+func (s *encryptedStore) View(fn func(tx StoreTx) error) error {
+	return s.store.View(func(tx StoreTx) error {
+		return (&encryptedTx{tx: tx, aead: s.aead}).run(fn)
+	})
+}
+
+// Update roll back the transaction when a value read failed to decrypt
+func (s *encryptedStore) Update(fn func(tx StoreTx) error) error {
+	return s.store.Update(func(tx StoreTx) error {
+		return (&encryptedTx{tx: tx, aead: s.aead}).run(fn)
+	})
+}
+
+// Watch decrypt the changes of the store
+func (s *encryptedStore) Watch(bucket string, prefix string) (<-chan Event, func()) {
+
+	events, cancel := s.store.Watch(bucket, prefix)
+	out := make(chan Event, 64)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case e := <-events:
+				if !e.Deleted && e.Bucket != metaBucket {
+					value, err := unseal(s.aead, e.Bucket, e.Key, e.Value)
+					if err != nil {
+						log.Errorf("Failed to decrypt the change of %s: %s", e.Key, err.Error())
+						continue
+					}
+					e.Value = value
+				}
+				select {
+				case out <- e:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			cancel()
+			close(done)
+		})
+	}
+}
+
+func (s *encryptedStore) Snapshot(w io.Writer) (int64, error) {
+	snapshotter, ok := s.store.(Snapshotter)
+	if !ok {
+		return 0, ErrSnapshotUnsupported
+	}
+	return snapshotter.Snapshot(w)
+}
+
+func (s *encryptedStore) Close() error {
+	return s.store.Close()
+}
+
+func (t *encryptedTx) Get(bucket string, key string) []byte {
+
+	value := t.tx.Get(bucket, key)
+	if value == nil || bucket == metaBucket {
+		return value
+	}
+
+	plain, err := unseal(t.aead, bucket, key, value)
+	if err != nil {
+		if t.err == nil {
+			t.err = err
+		}
+		return nil
+	}
+
+	return plain
+}
+
+func (t *encryptedTx) Put(bucket string, key string, value []byte) error {
+
+	if bucket == metaBucket {
+		return t.tx.Put(bucket, key, value)
+	}
+
+	sealed, err := seal(t.aead, bucket, key, value)
+	if err != nil {
+		return err
+	}
+
+	return t.tx.Put(bucket, key, sealed)
+}
+
+func (t *encryptedTx) Delete(bucket string, key string) error {
+	return t.tx.Delete(bucket, key)
+}
+
+func (t *encryptedTx) Scan(bucket string, prefix string, fn func(key string, value []byte) error) error {
+
+	if bucket == metaBucket {
+		return t.tx.Scan(bucket, prefix, fn)
+	}
+
+	return t.tx.Scan(bucket, prefix, func(key string, value []byte) error {
+		plain, err := unseal(t.aead, bucket, key, value)
+		if err != nil {
+			return err
+		}
+		return fn(key, plain)
+	})
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+)
+
+// withKey set the master key for a test
+func withKey(t *testing.T, key []byte) {
+	previous := encryptionKey
+	encryptionKey = key
+	t.Cleanup(func() { encryptionKey = previous })
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+
+	withKey(t, bytes.Repeat([]byte{1}, 32))
+	plain := NewMemoryStore()
+
+	store, err := encrypt(plain, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Update(func(tx StoreTx) error {
+		return tx.Put(rrBucket, "lan.local.foo_1", []byte("secret"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain.View(func(tx StoreTx) error {
+		if v := tx.Get(rrBucket, "lan.local.foo_1"); !isEncrypted(v) || bytes.Contains(v, []byte("secret")) {
+			t.Errorf("Value stored in clear: %q", v)
+		}
+		return nil
+	})
+
+	// the store is opened again with the same key
+	store, err = encrypt(plain, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.View(func(tx StoreTx) error {
+		if v := tx.Get(rrBucket, "lan.local.foo_1"); string(v) != "secret" {
+			t.Errorf("Get returned %q, want secret", v)
+		}
+		return tx.Scan(rrBucket, "", func(k string, v []byte) error {
+			if string(v) != "secret" {
+				t.Errorf("Scan returned %q, want secret", v)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptionWrongKey(t *testing.T) {
+
+	withKey(t, bytes.Repeat([]byte{1}, 32))
+	plain := NewMemoryStore()
+	if _, err := encrypt(plain, true); err != nil {
+		t.Fatal(err)
+	}
+
+	encryptionKey = bytes.Repeat([]byte{2}, 32)
+	if _, err := encrypt(plain, true); err != ErrEncryptionKeyWrong {
+		t.Errorf("Open with a wrong key returned %v", err)
+	}
+
+	encryptionKey = nil
+	if _, err := encrypt(plain, true); err != ErrEncryptionKeyMissing {
+		t.Errorf("Open without the key returned %v", err)
+	}
+}
+
+func TestEncryptionCorruptedValue(t *testing.T) {
+
+	withKey(t, bytes.Repeat([]byte{1}, 32))
+	plain := NewMemoryStore()
+	store, err := encrypt(plain, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store.Update(func(tx StoreTx) error {
+		return tx.Put(rrBucket, "lan.local.foo_1", []byte("secret"))
+	})
+	// a value sealed for another key does not decrypt
+	plain.Update(func(tx StoreTx) error {
+		return tx.Put(rrBucket, "lan.local.bar_1", tx.Get(rrBucket, "lan.local.foo_1"))
+	})
+
+	err = store.View(func(tx StoreTx) error {
+		if tx.Get(rrBucket, "lan.local.bar_1") != nil {
+			t.Errorf("Get of a corrupted value returned a value")
+		}
+		return nil
+	})
+	if err == nil {
+		t.Errorf("View should return the decryption failure")
+	}
+
+	err = store.Update(func(tx StoreTx) error {
+		tx.Get(rrBucket, "lan.local.bar_1")
+		return tx.Put(rrBucket, "lan.local.baz_1", []byte("value"))
+	})
+	if err == nil {
+		t.Errorf("Update should return the decryption failure")
+	}
+	store.View(func(tx StoreTx) error {
+		if tx.Get(rrBucket, "lan.local.baz_1") != nil {
+			t.Errorf("Update with a decryption failure not rolled back")
+		}
+		return nil
+	})
+}
+
+func TestEncryptionExistingValues(t *testing.T) {
+
+	plain := NewMemoryStore()
+	plain.Update(func(tx StoreTx) error {
+		return tx.Put(rrBucket, "lan.local.foo_1", []byte("secret"))
+	})
+
+	// the values stored before the encryption are encrypted when enabled
+	withKey(t, bytes.Repeat([]byte{1}, 32))
+	store, err := encrypt(plain, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain.View(func(tx StoreTx) error {
+		if v := tx.Get(rrBucket, "lan.local.foo_1"); !isEncrypted(v) {
+			t.Errorf("Existing value stored in clear: %q", v)
+		}
+		return nil
+	})
+	store.View(func(tx StoreTx) error {
+		if v := tx.Get(rrBucket, "lan.local.foo_1"); string(v) != "secret" {
+			t.Errorf("Get returned %q, want secret", v)
+		}
+		return nil
+	})
+
+	// a value written in clear to the encrypted store is refused
+	plain.Update(func(tx StoreTx) error {
+		return tx.Put(rrBucket, "lan.local.bar_1", []byte("forged"))
+	})
+	err = store.View(func(tx StoreTx) error {
+		return tx.Scan(rrBucket, "", func(k string, v []byte) error {
+			return nil
+		})
+	})
+	if err == nil {
+		t.Errorf("Scan of a value not encrypted should fail")
+	}
+}
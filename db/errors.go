@@ -0,0 +1,39 @@
+package db
+
+import "errors"
+
+// NotFoundError is returned when a record, lease or token is not stored
+type NotFoundError struct {
+	Message string
+}
+
+func (e NotFoundError) Error() string {
+	return e.Message
+}
+
+// ExpiredError is returned when a lease has no more records attached
+type ExpiredError struct {
+	Message string
+}
+
+func (e ExpiredError) Error() string {
+	return e.Message
+}
+
+//IsNotFound check if an error is caused by a missing item
+func IsNotFound(err error) bool {
+	_, ok := err.(NotFoundError)
+	return ok
+}
+
+//IsExpired check if an error is caused by an expired item
+func IsExpired(err error) bool {
+	_, ok := err.(ExpiredError)
+	return ok
+}
+
+// ErrReadOnly is returned when writing in a read-only transaction
+var ErrReadOnly = errors.New("Transaction is read-only")
+
+// ErrNotLeader is returned when writing on a cluster node which is not the leader
+var ErrNotLeader = errors.New("Not the cluster leader")
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// expiryBucket index the expiring records by expiration, the keys are
+// sorted by time so the expired records are found without a full scan
+const expiryBucket = "expiry"
+
+// errScanDone stop a scan early
+var errScanDone = errors.New("scan done")
+
+// expiryKey return the index key of a record expiring at a unix time
+func expiryKey(expires int64, key string) string {
+	return fmt.Sprintf("%016x/%s", expires, key)
+}
+
+// parseExpiryKey return the expiration and the record key of an index key
+func parseExpiryKey(k string) (int64, string) {
+	parts := strings.SplitN(k, "/", 2)
+	if len(parts) != 2 {
+		return 0, ""
+	}
+	expires, _ := strconv.ParseInt(parts[0], 16, 64)
+	return expires, parts[1]
+}
+
+// setExpiry move a record in the expiry index, an expiration of 0 is not indexed
+func setExpiry(tx StoreTx, key string, old int64, expires int64) error {
+
+	if old == expires {
+		return nil
+	}
+
+	if old > 0 {
+		if err := tx.Delete(expiryBucket, expiryKey(old, key)); err != nil {
+			return err
+		}
+	}
+
+	if expires > 0 {
+		return tx.Put(expiryBucket, expiryKey(expires, key), []byte(key))
+	}
+
+	return nil
+}
+
+//GetExpiredRecords return the keys of the expired records
+func (d *DB) GetExpiredRecords() ([]string, error) {
+
+	now := time.Now().Unix()
+	list := make([]string, 0)
+
+	err := d.store.View(func(tx StoreTx) error {
+		err := tx.Scan(expiryBucket, "", func(k string, v []byte) error {
+			expires, key := parseExpiryKey(k)
+			if expires >= now {
+				return errScanDone
+			}
+			list = append(list, key)
+			return nil
+		})
+		if err == errScanDone {
+			return nil
+		}
+		return err
+	})
+
+	if err != nil {
+		log.Errorf("Failed to list expired records: %s", err.Error())
+		return nil, err
+	}
+
+	return list, nil
+}
+
+//DeleteExpired remove a record if it is still expired, in a single transaction
+// so a record renewed after GetExpiredRecords is kept. Returns true if removed
+func (d *DB) DeleteExpired(origin Origin, key string) (deleted bool, err error) {
+	err = d.Update(origin, func(tx *Tx) error {
+
+		old, err := tx.previous(key)
+		if err != nil {
+			return err
+		}
+
+		if old == nil {
+			// drop the stale index entries
+			stale := make([]string, 0)
+			err := tx.tx.Scan(expiryBucket, "", func(k string, v []byte) error {
+				if _, indexed := parseExpiryKey(k); indexed == key {
+					stale = append(stale, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, k := range stale {
+				if err := tx.tx.Delete(expiryBucket, k); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if old.Expires == 0 || old.Expires >= time.Now().Unix() {
+			log.Debugf("Record %s renewed, not removed", key)
+			return nil
+		}
+
+		deleted = true
+		return tx.DeleteRecord(key)
+	})
+	return deleted && err == nil, err
+}
+
+// indexExpiry build the expiry index of the stored records
+func indexExpiry(tx StoreTx) error {
+	return tx.Scan(rrBucket, "", func(k string, v []byte) error {
+		r := Record{}
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		return setExpiry(tx, k, 0, r.Expires)
+	})
+}
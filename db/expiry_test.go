@@ -0,0 +1,85 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeleteExpired(t *testing.T) {
+
+	d := New(NewMemoryStore())
+	origin := Origin{Source: SourceAPI}
+	past := time.Now().Unix() - 60
+
+	for _, key := range []string{"lan.local.a_1", "lan.local.b_1"} {
+		if err := d.StoreRecord(origin, key, NewRecord(key, past)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.StoreRecord(origin, "lan.local.c_1", NewRecord("c", 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := d.GetExpiredRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 2 {
+		t.Fatalf("Expired records %v, want a and b", expired)
+	}
+
+	// b is renewed after the sweep listed it
+	if err := d.StoreRecord(origin, "lan.local.b_1", NewRecord("b", time.Now().Unix()+60)); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := d.DeleteExpired(Origin{Source: SourceExpiry}, "lan.local.a_1")
+	if err != nil || !deleted {
+		t.Errorf("Expired record a not deleted: %v", err)
+	}
+	deleted, err = d.DeleteExpired(Origin{Source: SourceExpiry}, "lan.local.b_1")
+	if err != nil || deleted {
+		t.Errorf("Renewed record b deleted: %v", err)
+	}
+
+	if _, err := d.GetRecord("lan.local.a_1"); !IsNotFound(err) {
+		t.Errorf("Record a found after expiry: %v", err)
+	}
+	if _, err := d.GetRecord("lan.local.b_1"); err != nil {
+		t.Errorf("Record b not found: %v", err)
+	}
+
+	expired, err = d.GetExpiredRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("Expired records %v after the sweep", expired)
+	}
+}
+
+func TestDeleteExpiredStaleIndex(t *testing.T) {
+
+	d := New(NewMemoryStore())
+	key := "lan.local.a_1"
+
+	err := d.store.Update(func(tx StoreTx) error {
+		return setExpiry(tx, key, 0, time.Now().Unix()-60)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := d.DeleteExpired(Origin{Source: SourceExpiry}, key)
+	if err != nil || deleted {
+		t.Errorf("DeleteExpired of a missing record returned %t, %v", deleted, err)
+	}
+
+	expired, err := d.GetExpiredRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("Stale index entries %v not removed", expired)
+	}
+}
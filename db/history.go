@@ -0,0 +1,215 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const historyBucket = "history"
+
+// Sources of the record changes
+const (
+	SourceAPI      = "api"
+	SourceNSUpdate = "nsupdate"
+	SourceExpiry   = "expiry"
+)
+
+// Origin of a change, stored in the record history
+type Origin struct {
+	Actor  string
+	Source string
+}
+
+// Change of a record set, Old is nil on creation and New on removal
+type Change struct {
+	Time   int64
+	Actor  string `json:",omitempty"`
+	Source string
+	Old    *Record `json:",omitempty"`
+	New    *Record `json:",omitempty"`
+}
+
+var (
+	historyLimit  = 20
+	historyMaxAge time.Duration
+)
+
+//SetHistoryRetention set the max number of changes kept for each record and
+// their max age, 0 to keep them
+func SetHistoryRetention(limit int, maxAge time.Duration) {
+	log.Debugf("History retention: %d changes, %s", limit, maxAge)
+	historyLimit = limit
+	historyMaxAge = maxAge
+}
+
+// changePrefix return the prefix of the history keys of a record
+func changePrefix(key string) string {
+	return key + "/"
+}
+
+// changeKey return the history key of a change, ordered by sequence
+func changeKey(key string, seq uint64) string {
+	return fmt.Sprintf("%s%016x", changePrefix(key), seq)
+}
+
+// changeSeq return the sequence of a history key
+func changeSeq(k string) uint64 {
+	seq, _ := strconv.ParseUint(k[strings.LastIndex(k, "/")+1:], 16, 64)
+	return seq
+}
+
+// addChange append a change to the history of a record
+func (t *Tx) addChange(key string, old *Record, new *Record) error {
+
+	last := ""
+	err := t.tx.Scan(historyBucket, changePrefix(key), func(k string, v []byte) error {
+		last = k
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	seq := uint64(1)
+	if last != "" {
+		seq = changeSeq(last) + 1
+	}
+
+	val, err := json.Marshal(Change{
+		Time:   time.Now().Unix(),
+		Actor:  t.origin.Actor,
+		Source: t.origin.Source,
+		Old:    old,
+		New:    new,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := t.tx.Put(historyBucket, changeKey(key, seq), val); err != nil {
+		return err
+	}
+
+	return pruneChanges(t.tx, changePrefix(key))
+}
+
+// pruneChanges drop the oldest changes exceeding the retention limits
+func pruneChanges(tx StoreTx, prefix string) error {
+
+	keys := make([]string, 0)
+	times := make([]int64, 0)
+	err := tx.Scan(historyBucket, prefix, func(k string, v []byte) error {
+		change := Change{}
+		if err := json.Unmarshal(v, &change); err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		times = append(times, change.Time)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	drop := 0
+	if historyLimit > 0 {
+		drop = len(keys) - historyLimit
+	}
+
+	minTime := int64(0)
+	if historyMaxAge > 0 {
+		minTime = time.Now().Add(-historyMaxAge).Unix()
+	}
+
+	for i, k := range keys {
+
+		if i >= drop && times[i] >= minTime {
+			break
+		}
+
+		if err := tx.Delete(historyBucket, k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//PruneHistory apply the retention limits to the history of all the records
+func (d *DB) PruneHistory() error {
+	return d.store.Update(func(tx StoreTx) error {
+
+		prefixes := make([]string, 0)
+		err := tx.Scan(historyBucket, "", func(k string, v []byte) error {
+			p := k[:strings.LastIndex(k, "/")+1]
+			if len(prefixes) == 0 || prefixes[len(prefixes)-1] != p {
+				prefixes = append(prefixes, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, p := range prefixes {
+			if err := pruneChanges(tx, p); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// changes return the history of a record, the most recent first
+func changes(tx StoreTx, key string) ([]Change, error) {
+	list := make([]Change, 0)
+	err := tx.Scan(historyBucket, changePrefix(key), func(k string, v []byte) error {
+		change := Change{}
+		if err := json.Unmarshal(v, &change); err != nil {
+			return err
+		}
+		list = append(list, change)
+		return nil
+	})
+
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+
+	return list, err
+}
+
+//GetHistory return the changes of a record, the most recent first
+func (d *DB) GetHistory(key string) (list []Change, err error) {
+	err = d.store.View(func(tx StoreTx) error {
+		list, err = changes(tx, key)
+		return err
+	})
+	return list, err
+}
+
+//GetVersion return a past version of a record from its history
+func (t *Tx) GetVersion(key string, version string) (Record, error) {
+
+	list, err := changes(t.tx, key)
+	if err != nil {
+		return Record{}, err
+	}
+
+	for _, change := range list {
+		if change.New != nil && change.New.ID == version {
+			return *change.New, nil
+		}
+		if change.Old != nil && change.Old.ID == version {
+			return *change.Old, nil
+		}
+	}
+
+	return Record{}, NotFoundError{Message: "Version not found: " + version}
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Lease store the records renewed together and the lease duration
+type Lease struct {
+	Keys     []string
+	Duration int64
+}
+
+//NewLeaseID generate a new lease ID
+func NewLeaseID() string {
+	return genid()
+}
+
+//StoreLease attach the records to a lease, creating it if needed
+func (d *DB) StoreLease(id string, duration int64, keys ...string) error {
+	return d.store.Update(func(tx StoreTx) error {
+		return (&Tx{tx: tx}).StoreLease(id, duration, keys...)
+	})
+}
+
+//RenewLease extend the expiration of the records attached to a lease.
+// Use a duration of 0 to keep the stored one, returns the new expiration
+func (d *DB) RenewLease(id string, duration int64) (expires int64, err error) {
+	err = d.store.Update(func(tx StoreTx) error {
+
+		raw := tx.Get(leaseBucket, id)
+		if raw == nil {
+			return NotFoundError{Message: "Lease not found: " + id}
+		}
+
+		lease := Lease{}
+		if err := json.Unmarshal(raw, &lease); err != nil {
+			return err
+		}
+
+		if duration > 0 {
+			lease.Duration = duration
+		}
+		expires = time.Now().Unix() + lease.Duration
+
+		keys := make([]string, 0, len(lease.Keys))
+		for _, key := range lease.Keys {
+
+			raw := tx.Get(rrBucket, key)
+			if raw == nil {
+				continue
+			}
+
+			r := Record{}
+			if err := json.Unmarshal(raw, &r); err != nil {
+				return err
+			}
+
+			// record replaced without the lease
+			if r.LeaseID != id {
+				continue
+			}
+
+			if err := setExpiry(tx, key, r.Expires, expires); err != nil {
+				return err
+			}
+
+			r.Expires = expires
+			val, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err := tx.Put(rrBucket, key, val); err != nil {
+				return err
+			}
+
+			keys = append(keys, key)
+		}
+
+		if len(keys) == 0 {
+			log.Debugf("Lease %s has no records, removing", id)
+			if err := tx.Delete(leaseBucket, id); err != nil {
+				return err
+			}
+			// the transaction is committed to drop the stale lease
+			expires = 0
+			return nil
+		}
+
+		lease.Keys = keys
+		return (&Tx{tx: tx}).putLease(id, lease)
+	})
+
+	if err == nil && expires == 0 {
+		err = ExpiredError{Message: "Lease expired: " + id}
+	}
+
+	return expires, err
+}
+
+//removeLeaseKey detach a record from its lease, removing the lease if empty
+func (t *Tx) removeLeaseKey(id string, key string) error {
+
+	raw := t.tx.Get(leaseBucket, id)
+	if raw == nil {
+		return nil
+	}
+
+	lease := Lease{}
+	if err := json.Unmarshal(raw, &lease); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(lease.Keys))
+	for _, k := range lease.Keys {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		log.Debugf("Removed lease %s", id)
+		return t.tx.Delete(leaseBucket, id)
+	}
+
+	lease.Keys = keys
+	return t.putLease(id, lease)
+}
+
+func (t *Tx) putLease(id string, lease Lease) error {
+	val, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	return t.tx.Put(leaseBucket, id, val)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+//GetLease return a lease by ID
+func (d *DB) GetLease(id string) (l Lease, err error) {
+	err = d.store.View(func(tx StoreTx) error {
+
+		raw := tx.Get(leaseBucket, id)
+		if raw == nil {
+			return NotFoundError{Message: "Lease not found: " + id}
+		}
+
+		return json.Unmarshal(raw, &l)
+	})
+	return l, err
+}
@@ -0,0 +1,35 @@
+// +build !windows
+
+package db
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockFile take an exclusive lock on a file, waiting up to timeout if it is
+// held by another process. The lock is released when the file is closed, or
+// by the system when the process exits
+func lockFile(path string, timeout time.Duration) (*os.File, error) {
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err != syscall.EWOULDBLOCK || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
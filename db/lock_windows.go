@@ -0,0 +1,12 @@
+package db
+
+import (
+	"os"
+	"time"
+)
+
+// lockFile open the lock file, the lock is not supported on Windows and
+// the database is not protected from other processes
+func lockFile(path string, timeout time.Duration) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+}
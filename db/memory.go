@@ -0,0 +1,142 @@
+package db
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// memoryStore keeps the data in memory, for embedded use and tests
+type memoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+	watchers
+}
+
+// memoryTx read from the store and buffer the changes until commit
+type memoryTx struct {
+	store   *memoryStore
+	changes map[string]map[string][]byte
+	deleted map[string]map[string]bool
+	events  []Event
+	write   bool
+}
+
+//NewMemoryStore create an empty in-memory store
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]map[string][]byte)}
+}
+
+func (s *memoryStore) View(fn func(tx StoreTx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&memoryTx{store: s})
+}
+
+func (s *memoryStore) Update(fn func(tx StoreTx) error) error {
+	s.mu.Lock()
+
+	tx := &memoryTx{
+		store:   s,
+		changes: make(map[string]map[string][]byte),
+		deleted: make(map[string]map[string]bool),
+		write:   true,
+	}
+
+	if err := fn(tx); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	for bucket, keys := range tx.deleted {
+		for key := range keys {
+			delete(s.buckets[bucket], key)
+		}
+	}
+	for bucket, keys := range tx.changes {
+		if s.buckets[bucket] == nil {
+			s.buckets[bucket] = make(map[string][]byte)
+		}
+		for key, value := range keys {
+			s.buckets[bucket][key] = value
+		}
+	}
+
+	s.mu.Unlock()
+	s.notify(tx.events)
+
+	return nil
+}
+
+func (s *memoryStore) Watch(bucket string, prefix string) (<-chan Event, func()) {
+	return s.add(bucket, prefix)
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+func (t *memoryTx) Get(bucket string, key string) []byte {
+	if value, ok := t.changes[bucket][key]; ok {
+		return value
+	}
+	if t.deleted[bucket][key] {
+		return nil
+	}
+	return t.store.buckets[bucket][key]
+}
+
+func (t *memoryTx) Put(bucket string, key string, value []byte) error {
+	if !t.write {
+		return ErrReadOnly
+	}
+	if t.changes[bucket] == nil {
+		t.changes[bucket] = make(map[string][]byte)
+	}
+	value = append([]byte{}, value...)
+	t.changes[bucket][key] = value
+	delete(t.deleted[bucket], key)
+	t.events = append(t.events, Event{Bucket: bucket, Key: key, Value: value})
+	return nil
+}
+
+func (t *memoryTx) Delete(bucket string, key string) error {
+	if !t.write {
+		return ErrReadOnly
+	}
+	if t.Get(bucket, key) == nil {
+		return nil
+	}
+	delete(t.changes[bucket], key)
+	if t.deleted[bucket] == nil {
+		t.deleted[bucket] = make(map[string]bool)
+	}
+	t.deleted[bucket][key] = true
+	t.events = append(t.events, Event{Bucket: bucket, Key: key, Deleted: true})
+	return nil
+}
+
+func (t *memoryTx) Scan(bucket string, prefix string, fn func(key string, value []byte) error) error {
+
+	keys := make([]string, 0)
+	for key := range t.store.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) && !t.deleted[bucket][key] {
+			if _, ok := t.changes[bucket][key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for key := range t.changes[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := fn(key, t.Get(bucket, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const metaBucket = "meta"
+const schemaVersionKey = "schema_version"
+
+// Migration upgrade the stored data to a schema version
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(tx StoreTx) error
+}
+
+// migrations are applied in order at open, add new ones at the end with the
+// next version and never change the released ones
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Index records by expiry",
+		Apply:       indexExpiry,
+	},
+	{
+		Version:     2,
+		Description: "Canonicalize record names",
+		Apply:       canonicalizeNames,
+	},
+}
+
+// errDryRun roll back the migrations of a dry run
+var errDryRun = errors.New("dry run")
+
+//SchemaVersion return the schema version of the stored data supported by this build
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// schemaVersion return the stored schema version, 0 if not set
+func schemaVersion(tx StoreTx) (int, error) {
+	raw := tx.Get(metaBucket, schemaVersionKey)
+	if raw == nil {
+		return 0, nil
+	}
+	return strconv.Atoi(string(raw))
+}
+
+// isEmpty check if a store has no records nor tokens
+func isEmpty(tx StoreTx) (bool, error) {
+	empty := true
+	for _, bucket := range []string{rrBucket, tokenBucket} {
+		err := tx.Scan(bucket, "", func(k string, v []byte) error {
+			empty = false
+			return errScanDone
+		})
+		if err != nil && err != errScanDone {
+			return false, err
+		}
+	}
+	return empty, nil
+}
+
+// migrate apply the pending migrations in a single transaction, saving a
+// backup of the store first. Returns the applied migrations
+func migrate(store Store, path string, dryRun bool) ([]Migration, error) {
+
+	var (
+		version int
+		empty   bool
+	)
+
+	err := store.View(func(tx StoreTx) (err error) {
+		if version, err = schemaVersion(tx); err != nil {
+			return err
+		}
+		empty, err = isEmpty(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if version > SchemaVersion() {
+		return nil, fmt.Errorf("Schema version %d is newer than the supported %d, upgrade ddns", version, SchemaVersion())
+	}
+
+	pending := migrations[version:]
+	if len(pending) == 0 {
+		log.Debugf("Schema version %d is current", version)
+		return pending, nil
+	}
+
+	if !dryRun && !empty {
+		if err := backup(store, path, version); err != nil {
+			return nil, err
+		}
+	}
+
+	err = store.Update(func(tx StoreTx) error {
+
+		for _, m := range pending {
+			log.Infof("Migrating schema to version %d: %s", m.Version, m.Description)
+			if err := m.Apply(tx); err != nil {
+				return fmt.Errorf("Migration to version %d failed: %s", m.Version, err.Error())
+			}
+		}
+
+		if err := tx.Put(metaBucket, schemaVersionKey, []byte(strconv.Itoa(SchemaVersion()))); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err == errDryRun {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// backup save a copy of the store next to its file before migrating
+func backup(store Store, path string, version int) error {
+
+	s, ok := store.(Snapshotter)
+	if !ok {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102150405"))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Snapshot(f); err != nil {
+		f.Close()
+		os.Remove(name)
+		return fmt.Errorf("Backup before migration failed: %s", err.Error())
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	log.Infof("Saved backup to %s", name)
+	return nil
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// legacyStore return a store with the records of a schema before the
+// migrations, stored by name as received
+func legacyStore(t *testing.T) Store {
+
+	store := NewMemoryStore()
+	expires := time.Now().Unix() + 60
+
+	records := map[string]Record{
+		"lan.local.foo_1": NewRecord("foo.local.lan.\t60\tIN\tA\t10.0.0.1", expires),
+		"lan.local.FOO_1": NewRecord("FOO.local.lan.\t60\tIN\tA\t10.0.0.2", 0),
+		"lan.Local.Bar_1": NewRecord("Bar.Local.lan.\t60\tIN\tA\t10.0.0.3", expires),
+	}
+
+	err := store.Update(func(tx StoreTx) error {
+		for key, r := range records {
+			val, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			if err := tx.Put(rrBucket, key, val); err != nil {
+				return err
+			}
+			change, err := json.Marshal(Change{Time: 1, Source: SourceAPI, New: &r})
+			if err != nil {
+				return err
+			}
+			if err := tx.Put(historyBucket, changeKey(key, 1), change); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestMigrate(t *testing.T) {
+
+	store := legacyStore(t)
+
+	applied, err := migrate(store, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != SchemaVersion() {
+		t.Errorf("Applied %d migrations, want %d", len(applied), SchemaVersion())
+	}
+
+	// the expiring records are indexed
+	indexed := 0
+	store.View(func(tx StoreTx) error {
+		return tx.Scan(expiryBucket, "", func(k string, v []byte) error {
+			indexed++
+			return nil
+		})
+	})
+	if indexed != 1 {
+		t.Errorf("Expiry index has %d entries, want 1", indexed)
+	}
+
+	store.View(func(tx StoreTx) error {
+		if v, _ := schemaVersion(tx); v != SchemaVersion() {
+			t.Errorf("Schema version is %d, want %d", v, SchemaVersion())
+		}
+		return nil
+	})
+
+	applied, err = migrate(store, "", false)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Migrate of a current store applied %d migrations: %v", len(applied), err)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+
+	store := legacyStore(t)
+
+	pending, err := migrate(store, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != SchemaVersion() {
+		t.Errorf("Dry run returned %d migrations, want %d", len(pending), SchemaVersion())
+	}
+
+	// nothing is changed
+	store.View(func(tx StoreTx) error {
+		if v, _ := schemaVersion(tx); v != 0 {
+			t.Errorf("Schema version is %d after a dry run", v)
+		}
+		if tx.Get(rrBucket, "lan.local.FOO_1") == nil {
+			t.Errorf("Record moved by a dry run")
+		}
+		return tx.Scan(expiryBucket, "", func(k string, v []byte) error {
+			t.Errorf("Expiry index %s added by a dry run", k)
+			return nil
+		})
+	})
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+
+	store := NewMemoryStore()
+	store.Update(func(tx StoreTx) error {
+		return tx.Put(metaBucket, schemaVersionKey, []byte(strconv.Itoa(SchemaVersion()+1)))
+	})
+
+	if _, err := migrate(store, "", false); err == nil {
+		t.Errorf("Migrate of a newer schema version should fail")
+	}
+}
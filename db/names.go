@@ -0,0 +1,273 @@
+package db
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/idna"
+)
+
+// canonicalLabel return a label lowercase, in punycode if Unicode
+func canonicalLabel(label string) string {
+	for i := 0; i < len(label); i++ {
+		if label[i] >= utf8.RuneSelf {
+			if ascii, err := idna.Lookup.ToASCII(label); err == nil {
+				label = ascii
+			}
+			break
+		}
+	}
+	return strings.ToLower(label)
+}
+
+// canonicalKey return the key of a record by canonical name,
+// eg. "com.Example.Foo_1" to "com.example.foo_1"
+func canonicalKey(key string) string {
+
+	i := strings.LastIndex(key, "_")
+	if i < 0 {
+		return key
+	}
+
+	labels := strings.Split(key[:i], ".")
+	for j, label := range labels {
+		labels[j] = canonicalLabel(label)
+	}
+
+	return strings.Join(labels, ".") + key[i:]
+}
+
+// canonicalRR return a record in presentation format with its owner name
+// canonical, eg. "Foo.Example.com.	60	IN	A	127.0.0.1"
+func canonicalRR(rr string) string {
+
+	fields := strings.Fields(rr)
+	if len(fields) == 0 {
+		return rr
+	}
+
+	owner := strings.TrimSuffix(fields[0], ".")
+	labels := strings.Split(owner, ".")
+	for i, label := range labels {
+		labels[i] = canonicalLabel(label)
+	}
+
+	return strings.Join(labels, ".") + "." + strings.TrimPrefix(rr, fields[0])
+}
+
+// canonicalizeNames move the records stored by a name with uppercase or
+// Unicode labels to their canonical name. When two record sets collide, their
+// values are merged
+func canonicalizeNames(tx StoreTx) error {
+
+	records := make(map[string]Record)
+	keys := make([]string, 0)
+	err := tx.Scan(rrBucket, "", func(k string, v []byte) error {
+		if canonicalKey(k) == k {
+			return nil
+		}
+		r := Record{}
+		if err := json.Unmarshal(v, &r); err != nil {
+			return err
+		}
+		records[k] = r
+		keys = append(keys, k)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+
+		r := records[key]
+		canonical := canonicalKey(key)
+
+		if err := tx.Delete(rrBucket, key); err != nil {
+			return err
+		}
+		if err := setExpiry(tx, key, r.Expires, 0); err != nil {
+			return err
+		}
+
+		r.RR = canonicalRR(r.RR)
+		for i := range r.Values {
+			r.Values[i].RR = canonicalRR(r.Values[i].RR)
+		}
+
+		previous := int64(0)
+		if raw := tx.Get(rrBucket, canonical); raw != nil {
+			stored := Record{}
+			if err := json.Unmarshal(raw, &stored); err != nil {
+				return err
+			}
+			log.Warnf("Record %s merged into %s, already stored", key, canonical)
+			previous = stored.Expires
+			r = mergeRecords(stored, r)
+		}
+
+		val, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if err := tx.Put(rrBucket, canonical, val); err != nil {
+			return err
+		}
+		if err := setExpiry(tx, canonical, previous, r.Expires); err != nil {
+			return err
+		}
+	}
+
+	if err := canonicalizeHistory(tx); err != nil {
+		return err
+	}
+
+	return canonicalizeLeases(tx)
+}
+
+// recordValues return the values of a record, the first value for the
+// records stored before the sets
+func recordValues(r Record) []Value {
+	if len(r.Values) == 0 && r.RR != "" {
+		return []Value{{RR: r.RR}}
+	}
+	return r.Values
+}
+
+// mergeRecords add the values of a record to a stored one of the same name,
+// the merged set expires with the last of them and gets a new version
+func mergeRecords(stored Record, r Record) Record {
+
+	values := recordValues(stored)
+	seen := make(map[string]bool)
+	for _, v := range values {
+		seen[v.RR] = true
+	}
+	for _, v := range recordValues(r) {
+		if !seen[v.RR] {
+			seen[v.RR] = true
+			values = append(values, v)
+		}
+	}
+
+	if stored.Expires != 0 && (r.Expires == 0 || r.Expires > stored.Expires) {
+		stored.Expires = r.Expires
+	}
+	if stored.Policy == "" {
+		stored.Policy = r.Policy
+	}
+	if stored.Check == nil {
+		stored.Check = r.Check
+	}
+	if stored.LeaseID == "" {
+		stored.LeaseID = r.LeaseID
+	}
+
+	stored.Values = values
+	if len(values) > 0 {
+		stored.RR = values[0].RR
+	}
+	stored.ID = genid()
+
+	return stored
+}
+
+// canonicalizeHistory move the changes of the records to their canonical key
+func canonicalizeHistory(tx StoreTx) error {
+
+	changes := make(map[string][]byte)
+	keys := make([]string, 0)
+	err := tx.Scan(historyBucket, "", func(k string, v []byte) error {
+		i := strings.LastIndex(k, "/")
+		if i < 0 || canonicalKey(k[:i]) == k[:i] {
+			return nil
+		}
+		changes[k] = append([]byte{}, v...)
+		keys = append(keys, k)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+
+		if err := tx.Delete(historyBucket, k); err != nil {
+			return err
+		}
+
+		i := strings.LastIndex(k, "/")
+		canonical := canonicalKey(k[:i]) + k[i:]
+		if tx.Get(historyBucket, canonical) != nil {
+			// the changes of merged records follow the ones already stored
+			last := ""
+			err := tx.Scan(historyBucket, changePrefix(canonicalKey(k[:i])), func(k string, v []byte) error {
+				last = k
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			canonical = changeKey(canonicalKey(k[:i]), changeSeq(last)+1)
+		}
+		if err := tx.Put(historyBucket, canonical, changes[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// canonicalizeLeases update the keys of the records attached to the leases
+func canonicalizeLeases(tx StoreTx) error {
+
+	leases := make(map[string]Lease)
+	err := tx.Scan(leaseBucket, "", func(k string, v []byte) error {
+		lease := Lease{}
+		if err := json.Unmarshal(v, &lease); err != nil {
+			return err
+		}
+		leases[k] = lease
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for id, lease := range leases {
+
+		changed := false
+		seen := make(map[string]bool)
+		keys := make([]string, 0, len(lease.Keys))
+		for _, key := range lease.Keys {
+			canonical := canonicalKey(key)
+			if canonical != key {
+				changed = true
+			}
+			if seen[canonical] {
+				continue
+			}
+			seen[canonical] = true
+			keys = append(keys, canonical)
+		}
+		if !changed {
+			continue
+		}
+
+		lease.Keys = keys
+		val, err := json.Marshal(lease)
+		if err != nil {
+			return err
+		}
+		if err := tx.Put(leaseBucket, id, val); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
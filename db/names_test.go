@@ -0,0 +1,38 @@
+package db
+
+import "testing"
+
+func TestCanonicalizeNames(t *testing.T) {
+
+	store := legacyStore(t)
+	if _, err := migrate(store, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	d := New(store)
+	records, err := d.GetRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Records after migration %v, want foo and bar", records)
+	}
+
+	// the colliding record sets are merged, the merged set does not expire
+	foo := records["lan.local.foo_1"]
+	if len(foo.Values) != 2 || foo.Expires != 0 {
+		t.Errorf("Merged record is %+v", foo)
+	}
+	bar, ok := records["lan.local.bar_1"]
+	if !ok || bar.RR != "bar.local.lan.\t60\tIN\tA\t10.0.0.3" {
+		t.Errorf("Canonical record is %+v", bar)
+	}
+
+	history, err := d.GetHistory("lan.local.foo_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Errorf("Merged history has %d changes, want 2", len(history))
+	}
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// buckets are all the buckets of the store, copied to the replicas
+var buckets = []string{rrBucket, leaseBucket, tokenBucket, historyBucket, expiryBucket, metaBucket, clusterBucket}
+
+// localKey check if a key belongs to the node and is not copied to the
+// replicas, as the data key of each store is encrypted with its own master key
+func localKey(bucket string, key string) bool {
+	return bucket == metaBucket && key == dataKeyName
+}
+
+// feedBuffer is the number of changes queued for a replica before it is
+// dropped, to resync from a snapshot
+const feedBuffer = 1024
+
+// FeedEvent is an event committed to the store, numbered by the feed
+type FeedEvent struct {
+	Event
+	Seq  uint64
+	Time time.Time
+}
+
+// Feed number the changes committed to a DB and dispatch them to the replicas
+type Feed struct {
+	mu   sync.Mutex
+	seq  uint64
+	last time.Time
+	subs map[chan FeedEvent]bool
+}
+
+//Feed return the change feed of the DB, started on first use
+func (d *DB) Feed() *Feed {
+	d.feedOnce.Do(func() {
+		d.feed = &Feed{subs: make(map[chan FeedEvent]bool)}
+		events, _ := d.store.Watch("", "")
+		go d.feed.run(events)
+	})
+	return d.feed
+}
+
+func (f *Feed) run(events <-chan Event) {
+	for e := range events {
+		f.mu.Lock()
+		f.seq++
+		f.last = time.Now()
+		change := FeedEvent{Event: e, Seq: f.seq, Time: f.last}
+		for sub := range f.subs {
+			select {
+			case sub <- change:
+			default:
+				log.Warnf("Replica too slow, dropping it at change %d", change.Seq)
+				delete(f.subs, sub)
+				close(sub)
+			}
+		}
+		f.mu.Unlock()
+	}
+}
+
+//Subscribe return the changes committed from now on, the channel is closed
+// if the subscriber falls behind. Call the returned function to unsubscribe
+func (f *Feed) Subscribe() (<-chan FeedEvent, func()) {
+
+	sub := make(chan FeedEvent, feedBuffer)
+
+	f.mu.Lock()
+	f.subs[sub] = true
+	f.mu.Unlock()
+
+	cancel := func() {
+		f.mu.Lock()
+		if f.subs[sub] {
+			delete(f.subs, sub)
+			close(sub)
+		}
+		f.mu.Unlock()
+	}
+
+	return sub, cancel
+}
+
+//Position return the sequence and time of the last change
+func (f *Feed) Position() (uint64, time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seq, f.last
+}
+
+//Subscribers return the number of subscribers
+func (f *Feed) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+//Dump call fn with all the keys of the store, from a consistent view. The keys
+// are copied first and fn is called outside the transaction, which would block
+// the writes while a slow replica receives them
+func (d *DB) Dump(fn func(e Event) error) error {
+
+	dump := make([]Event, 0)
+	err := d.store.View(func(tx StoreTx) error {
+		for _, bucket := range buckets {
+			err := tx.Scan(bucket, "", func(k string, v []byte) error {
+				if localKey(bucket, k) {
+					return nil
+				}
+				dump = append(dump, Event{Bucket: bucket, Key: k, Value: append([]byte{}, v...)})
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range dump {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Load replace the content of the store with a dump, in a single transaction
+func (d *DB) Load(dump []Event) error {
+	return d.store.Update(func(tx StoreTx) error {
+
+		keep := make(map[string]map[string]bool)
+		for _, e := range dump {
+			if keep[e.Bucket] == nil {
+				keep[e.Bucket] = make(map[string]bool)
+			}
+			keep[e.Bucket][e.Key] = true
+		}
+
+		for _, bucket := range buckets {
+			stale := make([]string, 0)
+			err := tx.Scan(bucket, "", func(k string, v []byte) error {
+				if !keep[bucket][k] && !localKey(bucket, k) {
+					stale = append(stale, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, k := range stale {
+				if err := tx.Delete(bucket, k); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, e := range dump {
+			if localKey(e.Bucket, e.Key) {
+				continue
+			}
+			if err := tx.Put(e.Bucket, e.Key, e.Value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+//Apply write the changes received from the primary
+func (d *DB) Apply(events ...Event) error {
+	return d.store.Update(func(tx StoreTx) error {
+		for _, e := range events {
+			if localKey(e.Bucket, e.Key) {
+				continue
+			}
+			var err error
+			if e.Deleted {
+				err = tx.Delete(e.Bucket, e.Key)
+			} else {
+				err = tx.Put(e.Bucket, e.Key, e.Value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const snapshotPrefix = "ddns-"
+const snapshotSuffix = ".snapshot"
+
+// ErrSnapshotUnsupported is returned by the stores without a persistent copy
+var ErrSnapshotUnsupported = errors.New("Snapshots are not supported by the storage")
+
+//Snapshot write a consistent copy of the database, while it is in use
+func (d *DB) Snapshot(w io.Writer) (int64, error) {
+	s, ok := d.store.(Snapshotter)
+	if !ok {
+		return 0, ErrSnapshotUnsupported
+	}
+	return s.Snapshot(w)
+}
+
+//SaveSnapshot save a snapshot in a directory, keeping the most recent ones.
+// Returns the path of the snapshot
+func (d *DB) SaveSnapshot(dir string, keep int) (string, error) {
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(dir, snapshotPrefix+time.Now().Format("20060102150405")+snapshotSuffix)
+	tmp := name + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := d.Snapshot(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	// a partial snapshot is never listed
+	if err := os.Rename(tmp, name); err != nil {
+		return "", err
+	}
+
+	log.Debugf("Saved snapshot %s", name)
+	return name, pruneSnapshots(dir, keep)
+}
+
+// pruneSnapshots remove the oldest snapshots of a directory
+func pruneSnapshots(dir string, keep int) error {
+
+	if keep <= 0 {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// the names sort by time
+	names := make([]string, 0)
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), snapshotPrefix) && strings.HasSuffix(f.Name(), snapshotSuffix) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for i := 0; i < len(names)-keep; i++ {
+		log.Debugf("Removing snapshot %s", names[i])
+		if err := os.Remove(filepath.Join(dir, names[i])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Restore replace a database with a snapshot, the service must be stopped.
+// The replaced database is kept next to it, its path is returned
+func Restore(storage string, snapshot string) (string, error) {
+
+	parts := strings.SplitN(storage, "://", 2)
+	if len(parts) != 2 || (parts[0] != "bolt" && parts[0] != "sqlite") {
+		return "", errors.New("Restore is supported only for bolt and sqlite storage: " + storage)
+	}
+	path := parts[1]
+
+	// fail if the database is in use. SQLite does not lock the file while
+	// idle, the lock of the store is held during the restore
+	if parts[0] == "sqlite" {
+		lock, err := lockFile(path+".lock", 0)
+		if err != nil {
+			return "", fmt.Errorf("Database %s is in use, is ddns running? %s", path, err.Error())
+		}
+		defer lock.Close()
+	} else if _, err := os.Stat(path); err == nil {
+		store, _, err := openStore(storage)
+		if err != nil {
+			return "", fmt.Errorf("Database %s can not be opened, is ddns running? %s", path, err.Error())
+		}
+		store.Close()
+	}
+
+	tmp := path + ".restore"
+	if err := copyFile(snapshot, tmp); err != nil {
+		return "", err
+	}
+
+	err := checkSnapshot(parts[0] + "://" + tmp)
+	if parts[0] == "sqlite" {
+		removeFile(tmp + ".lock")
+	}
+	if err != nil {
+		removeFile(tmp)
+		return "", fmt.Errorf("Snapshot %s is not valid: %s", snapshot, err.Error())
+	}
+
+	previous := ""
+	if _, err := os.Stat(path); err == nil {
+		previous = fmt.Sprintf("%s.pre-restore-%s", path, time.Now().Format("20060102150405"))
+		if err := moveFile(path, previous); err != nil {
+			removeFile(tmp)
+			return "", err
+		}
+	}
+
+	if err := moveFile(tmp, path); err != nil {
+		return previous, err
+	}
+
+	log.Infof("Restored %s from %s", path, snapshot)
+	return previous, nil
+}
+
+// checkSnapshot open a snapshot and check its schema and encryption are supported
+func checkSnapshot(storage string) error {
+
+	store, _, err := openStore(storage)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	return store.View(func(tx StoreTx) error {
+		version, err := schemaVersion(tx)
+		if err != nil {
+			return err
+		}
+		if version > SchemaVersion() {
+			return fmt.Errorf("Schema version %d is newer than the supported %d", version, SchemaVersion())
+		}
+		// an encrypted snapshot must be readable with the current key
+		_, err = dataKey(tx, encryptionKey)
+		return err
+	})
+}
+
+func copyFile(src string, dst string) error {
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// sqliteFiles are the suffixes of the files kept by SQLite next to a database
+var sqliteFiles = []string{"-wal", "-shm"}
+
+// moveFile rename a database file, with the SQLite files if any
+func moveFile(src string, dst string) error {
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+	for _, suffix := range sqliteFiles {
+		if _, err := os.Stat(src + suffix); err == nil {
+			if err := os.Rename(src+suffix, dst+suffix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeFile remove a database file, with the SQLite files if any
+func removeFile(path string) {
+	os.Remove(path)
+	for _, suffix := range sqliteFiles {
+		os.Remove(path + suffix)
+	}
+}
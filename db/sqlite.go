@@ -0,0 +1,359 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	// pure-Go driver, builds with CGO_ENABLED=0
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations upgrade the schema, applied in order and tracked in the
+// schema_migrations table. Add new steps at the end, never change the applied ones
+var sqliteMigrations = []string{
+	`CREATE TABLE kv (
+		bucket TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value BLOB NOT NULL,
+		PRIMARY KEY (bucket, key)
+	);
+	CREATE TABLE records (
+		key TEXT NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		expires INTEGER NOT NULL DEFAULT 0,
+		value BLOB NOT NULL
+	);
+	CREATE INDEX records_name ON records (name);
+	CREATE INDEX records_type ON records (type);
+	CREATE INDEX records_expires ON records (expires);`,
+}
+
+// sqliteStore save the records in a table with their name, type and expiry
+// to be queried with SQL, the other buckets in a key-value table
+type sqliteStore struct {
+	db *sql.DB
+	// lock is held while the store is open, SQLite does not lock the file
+	// between the transactions
+	lock *os.File
+	watchers
+}
+
+type sqliteTx struct {
+	tx     *sql.Tx
+	events []Event
+	write  bool
+}
+
+//NewSQLiteStore open a SQLite database file, migrating the schema to the latest version
+func NewSQLiteStore(path string) (Store, error) {
+
+	// other processes can read the database, while only one opens the store
+	lock, err := lockFile(path+".lock", 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("Database %s is in use by another process: %s", path, err.Error())
+	}
+
+	// WAL let other processes read the database while ddns runs
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+
+	// a single connection serialize the transactions of the process
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		lock.Close()
+		return nil, err
+	}
+
+	return &sqliteStore{db: db, lock: lock}, nil
+}
+
+// migrateSQLite apply the missing migrations
+func migrateSQLite(db *sql.DB) error {
+
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		applied INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+
+		log.Debugf("Applying SQLite migration %d", i+1)
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, applied) VALUES (?, ?)`, i+1, time.Now().Unix())
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqliteStore) View(fn func(tx StoreTx) error) error {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return fn(&sqliteTx{tx: tx})
+}
+
+func (s *sqliteStore) Update(fn func(tx StoreTx) error) error {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stx := &sqliteTx{tx: tx, write: true}
+	if err := fn(stx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.notify(stx.events)
+	return nil
+}
+
+func (s *sqliteStore) Watch(bucket string, prefix string) (<-chan Event, func()) {
+	return s.add(bucket, prefix)
+}
+
+func (s *sqliteStore) Snapshot(w io.Writer) (int64, error) {
+
+	dir, err := ioutil.TempDir("", "ddns-snapshot")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	// VACUUM INTO write a consistent copy, even while the store is used
+	name := filepath.Join(dir, "snapshot.sqlite")
+	if _, err := s.db.Exec(`VACUUM INTO ?`, name); err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(w, f)
+}
+
+func (s *sqliteStore) Close() error {
+	err := s.db.Close()
+	s.lock.Close()
+	return err
+}
+
+// vacuumSQLite rewrite a SQLite file without its free pages
+func vacuumSQLite(path string) error {
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(10000)")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+	return err
+}
+
+// recordColumns extract the name, type and expiry of a stored record
+func recordColumns(value []byte) (name string, rtype string, expires int64, err error) {
+
+	// the encrypted records are stored without columns, the store receives
+	// them sealed and the columns would keep their content in clear
+	if isEncrypted(value) {
+		return "", "", 0, nil
+	}
+
+	r := Record{}
+	if err = json.Unmarshal(value, &r); err != nil {
+		return
+	}
+
+	// eg. "foo.example.com.	60	IN	A	127.0.0.1"
+	fields := strings.Fields(r.RR)
+	if len(fields) > 3 {
+		name = strings.ToLower(fields[0])
+		rtype = fields[3]
+	}
+
+	return name, rtype, r.Expires, nil
+}
+
+func (t *sqliteTx) Get(bucket string, key string) []byte {
+
+	var (
+		value []byte
+		err   error
+	)
+
+	if bucket == rrBucket {
+		err = t.tx.QueryRow(`SELECT value FROM records WHERE key = ?`, key).Scan(&value)
+	} else {
+		err = t.tx.QueryRow(`SELECT value FROM kv WHERE bucket = ? AND key = ?`, bucket, key).Scan(&value)
+	}
+
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Errorf("SQLite read failed: %s", err.Error())
+		}
+		return nil
+	}
+
+	return value
+}
+
+func (t *sqliteTx) Put(bucket string, key string, value []byte) error {
+
+	if !t.write {
+		return ErrReadOnly
+	}
+
+	if bucket == rrBucket {
+		name, rtype, expires, err := recordColumns(value)
+		if err != nil {
+			return err
+		}
+		_, err = t.tx.Exec(`INSERT INTO records (key, name, type, expires, value) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET name = excluded.name, type = excluded.type,
+			expires = excluded.expires, value = excluded.value`, key, name, rtype, expires, value)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := t.tx.Exec(`INSERT INTO kv (bucket, key, value) VALUES (?, ?, ?)
+			ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`, bucket, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.events = append(t.events, Event{Bucket: bucket, Key: key, Value: append([]byte{}, value...)})
+	return nil
+}
+
+func (t *sqliteTx) Delete(bucket string, key string) error {
+
+	if !t.write {
+		return ErrReadOnly
+	}
+
+	var (
+		res sql.Result
+		err error
+	)
+
+	if bucket == rrBucket {
+		res, err = t.tx.Exec(`DELETE FROM records WHERE key = ?`, key)
+	} else {
+		res, err = t.tx.Exec(`DELETE FROM kv WHERE bucket = ? AND key = ?`, bucket, key)
+	}
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n > 0 {
+		t.events = append(t.events, Event{Bucket: bucket, Key: key, Deleted: true})
+	}
+
+	return nil
+}
+
+func (t *sqliteTx) Scan(bucket string, prefix string, fn func(key string, value []byte) error) error {
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	if bucket == rrBucket {
+		rows, err = t.tx.Query(`SELECT key, value FROM records WHERE key >= ? ORDER BY key`, prefix)
+	} else {
+		rows, err = t.tx.Query(`SELECT key, value FROM kv WHERE bucket = ? AND key >= ? ORDER BY key`, bucket, prefix)
+	}
+	if err != nil {
+		return err
+	}
+
+	// read the rows first, fn can change the bucket
+	keys := make([]string, 0)
+	values := make([][]byte, 0)
+	for rows.Next() {
+		var (
+			key   string
+			value []byte
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		if err := fn(key, values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
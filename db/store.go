@@ -0,0 +1,114 @@
+package db
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// Store is a transactional key-value storage organized in buckets
+type Store interface {
+	// View run a read-only transaction
+	View(fn func(tx StoreTx) error) error
+	// Update run a read-write transaction, discarded if fn returns an error
+	Update(fn func(tx StoreTx) error) error
+	// Watch return the changes committed to the keys of a bucket with a prefix,
+	// until the returned function is called. An empty bucket watch all of them
+	Watch(bucket string, prefix string) (<-chan Event, func())
+	Close() error
+}
+
+// Snapshotter is implemented by the stores which can write a consistent copy
+// of their data, restored by replacing the store file
+type Snapshotter interface {
+	Snapshot(w io.Writer) (int64, error)
+}
+
+// StoreTx access the buckets of a Store in a transaction
+type StoreTx interface {
+	// Get return the value of a key, nil if not found. The value is valid
+	// only in the transaction
+	Get(bucket string, key string) []byte
+	Put(bucket string, key string, value []byte) error
+	Delete(bucket string, key string) error
+	// Scan call fn for the keys with a prefix, in order. The scan stops if fn
+	// returns an error
+	Scan(bucket string, prefix string, fn func(key string, value []byte) error) error
+}
+
+// Event is a change committed to a Store
+type Event struct {
+	Bucket  string
+	Key     string
+	Value   []byte
+	Deleted bool
+}
+
+type watcher struct {
+	bucket string
+	prefix string
+	events chan Event
+	done   chan struct{}
+}
+
+// watchers dispatch the committed changes of a Store
+type watchers struct {
+	mu   sync.Mutex
+	list map[*watcher]bool
+}
+
+func (w *watchers) add(bucket string, prefix string) (<-chan Event, func()) {
+
+	wt := &watcher{
+		bucket: bucket,
+		prefix: prefix,
+		events: make(chan Event, 64),
+		done:   make(chan struct{}),
+	}
+
+	w.mu.Lock()
+	if w.list == nil {
+		w.list = make(map[*watcher]bool)
+	}
+	w.list[wt] = true
+	w.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.list, wt)
+			w.mu.Unlock()
+			close(wt.done)
+		})
+	}
+
+	return wt.events, cancel
+}
+
+// notify send the events to the watchers, waiting for slow readers
+func (w *watchers) notify(events []Event) {
+
+	if len(events) == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	list := make([]*watcher, 0, len(w.list))
+	for wt := range w.list {
+		list = append(list, wt)
+	}
+	w.mu.Unlock()
+
+	for _, wt := range list {
+		for _, e := range events {
+			if (wt.bucket != "" && e.Bucket != wt.bucket) || !strings.HasPrefix(e.Key, wt.prefix) {
+				continue
+			}
+			select {
+			case wt.events <- e:
+			case <-wt.done:
+			}
+		}
+	}
+}
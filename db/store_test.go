@@ -0,0 +1,153 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStoreTx(t *testing.T) {
+
+	store := NewMemoryStore()
+
+	err := store.Update(func(tx StoreTx) error {
+		for _, k := range []string{"b/2", "a/1", "b/1"} {
+			if err := tx.Put("bucket", k, []byte(k)); err != nil {
+				return err
+			}
+		}
+		// the changes are read in the transaction
+		if string(tx.Get("bucket", "a/1")) != "a/1" {
+			t.Errorf("Put value not read in the transaction")
+		}
+		return tx.Delete("bucket", "a/1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.View(func(tx StoreTx) error {
+		if tx.Get("bucket", "a/1") != nil {
+			t.Errorf("Deleted key a/1 found")
+		}
+		if tx.Get("other", "b/1") != nil {
+			t.Errorf("Key found in the wrong bucket")
+		}
+
+		keys := make([]string, 0)
+		err := tx.Scan("bucket", "b/", func(k string, v []byte) error {
+			if string(v) != k {
+				t.Errorf("Scan value of %s is %s", k, v)
+			}
+			keys = append(keys, k)
+			return nil
+		})
+		if len(keys) != 2 || keys[0] != "b/1" || keys[1] != "b/2" {
+			t.Errorf("Scan returned %v, want [b/1 b/2]", keys)
+		}
+
+		if err := tx.Put("bucket", "c", []byte("c")); err != ErrReadOnly {
+			t.Errorf("Put in a read-only transaction returned %v", err)
+		}
+		if err := tx.Delete("bucket", "b/1"); err != ErrReadOnly {
+			t.Errorf("Delete in a read-only transaction returned %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreRollback(t *testing.T) {
+
+	store := NewMemoryStore()
+	failed := errors.New("failed")
+
+	err := store.Update(func(tx StoreTx) error {
+		return tx.Put("bucket", "key", []byte("old"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Update(func(tx StoreTx) error {
+		if err := tx.Put("bucket", "key", []byte("new")); err != nil {
+			return err
+		}
+		if err := tx.Put("bucket", "other", []byte("new")); err != nil {
+			return err
+		}
+		return failed
+	})
+	if err != failed {
+		t.Fatalf("Update returned %v, want %v", err, failed)
+	}
+
+	store.View(func(tx StoreTx) error {
+		if v := tx.Get("bucket", "key"); string(v) != "old" {
+			t.Errorf("Rolled back value is %s, want old", v)
+		}
+		if tx.Get("bucket", "other") != nil {
+			t.Errorf("Rolled back key stored")
+		}
+		return nil
+	})
+}
+
+// nextEvent return the next event of a watch, failing after a timeout
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("Event not received")
+	}
+	return Event{}
+}
+
+func TestStoreWatch(t *testing.T) {
+
+	store := NewMemoryStore()
+	events, cancel := store.Watch("bucket", "a")
+	defer cancel()
+
+	store.Update(func(tx StoreTx) error {
+		tx.Put("bucket", "b", []byte("b"))
+		tx.Put("other", "a", []byte("a"))
+		return tx.Put("bucket", "a", []byte("a"))
+	})
+	store.Update(func(tx StoreTx) error {
+		tx.Put("bucket", "a2", []byte("a2"))
+		return errors.New("rollback")
+	})
+	store.Update(func(tx StoreTx) error {
+		return tx.Delete("bucket", "a")
+	})
+
+	e := nextEvent(t, events)
+	if e.Bucket != "bucket" || e.Key != "a" || string(e.Value) != "a" || e.Deleted {
+		t.Errorf("Put event is %+v", e)
+	}
+
+	// the rolled back and filtered changes are not sent
+	e = nextEvent(t, events)
+	if e.Key != "a" || !e.Deleted {
+		t.Errorf("Delete event is %+v", e)
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		store.Update(func(tx StoreTx) error {
+			return tx.Put("bucket", "a", []byte("a"))
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Update blocked by a cancelled watch")
+	}
+}
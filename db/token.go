@@ -0,0 +1,92 @@
+package db
+
+import (
+	"encoding/json"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Token store an API token, indexed by the hash of its secret
+type Token struct {
+	ID      string
+	Name    string
+	Scopes  []string
+	Created int64
+}
+
+//NewTokenID generate a new token ID
+func NewTokenID() string {
+	return genid()
+}
+
+//StoreToken save a token by the hash of its secret
+func (d *DB) StoreToken(hash string, token Token) error {
+	return d.store.Update(func(tx StoreTx) error {
+
+		val, err := json.Marshal(token)
+		if err != nil {
+			return err
+		}
+
+		return tx.Put(tokenBucket, hash, val)
+	})
+}
+
+//GetToken return the token matching the hash of a secret
+func (d *DB) GetToken(hash string) (t Token, err error) {
+	err = d.store.View(func(tx StoreTx) error {
+
+		raw := tx.Get(tokenBucket, hash)
+		if raw == nil {
+			return NotFoundError{Message: "Token not found"}
+		}
+
+		return json.Unmarshal(raw, &t)
+	})
+	return t, err
+}
+
+//GetTokens return the stored tokens
+func (d *DB) GetTokens() ([]Token, error) {
+	list := make([]Token, 0)
+	err := d.store.View(func(tx StoreTx) error {
+		return tx.Scan(tokenBucket, "", func(k string, v []byte) error {
+			t := Token{}
+			if err := json.Unmarshal(v, &t); err != nil {
+				log.Errorf("Token unmarshalling failed: %s", err.Error())
+				return err
+			}
+			list = append(list, t)
+			return nil
+		})
+	})
+	return list, err
+}
+
+//DeleteToken remove a token by ID
+func (d *DB) DeleteToken(id string) error {
+	return d.store.Update(func(tx StoreTx) error {
+
+		hash := ""
+		err := tx.Scan(tokenBucket, "", func(k string, v []byte) error {
+			t := Token{}
+			if err := json.Unmarshal(v, &t); err != nil {
+				return err
+			}
+			if t.ID == id {
+				hash = k
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if hash == "" {
+			return NotFoundError{Message: "Token not found: " + id}
+		}
+
+		log.Debugf("Removed token %s", id)
+		return tx.Delete(tokenBucket, hash)
+	})
+}
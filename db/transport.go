@@ -0,0 +1,66 @@
+package db
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// tlsStreamLayer carry the Raft traffic over mutual TLS, the nodes must
+// present a certificate signed by the cluster CA
+type tlsStreamLayer struct {
+	net.Listener
+	advertise net.Addr
+	config    *tls.Config
+}
+
+// newTLSStreamLayer listen on the Raft address of the node
+func newTLSStreamLayer(address string, config *tls.Config) (*tlsStreamLayer, error) {
+
+	advertise, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if advertise.IP == nil || advertise.IP.IsUnspecified() {
+		return nil, errors.New("Cluster bind address must be reachable by the other nodes: " + address)
+	}
+
+	server := config.Clone()
+	server.ClientAuth = tls.RequireAndVerifyClientCert
+	server.ClientCAs = config.RootCAs
+
+	listener, err := tls.Listen("tcp", address, server)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tlsStreamLayer{Listener: listener, advertise: advertise, config: config}, nil
+}
+
+// Addr return the address advertised to the other nodes
+func (s *tlsStreamLayer) Addr() net.Addr {
+	return s.advertise
+}
+
+// Dial connect to a node, verifying its certificate
+func (s *tlsStreamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", string(address), s.config)
+}
+
+// isLoopback check if the host of an address is a loopback address
+func isLoopback(address string) bool {
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Tx group reads and changes of records and leases in a single transaction
+type Tx struct {
+	tx     StoreTx
+	origin Origin
+}
+
+//Update run a read-write transaction, nothing is stored if fn returns an error.
+// The record changes are added to the history with their origin
+func (d *DB) Update(origin Origin, fn func(tx *Tx) error) error {
+	return d.store.Update(func(tx StoreTx) error {
+		return fn(&Tx{tx: tx, origin: origin})
+	})
+}
+
+//View run a read-only transaction
+func (d *DB) View(fn func(tx *Tx) error) error {
+	return d.store.View(func(tx StoreTx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+// previous return the stored record, if any
+func (t *Tx) previous(key string) (*Record, error) {
+
+	raw := t.tx.Get(rrBucket, key)
+	if raw == nil {
+		return nil, nil
+	}
+
+	r := Record{}
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+//GetRecord return a stored record
+func (t *Tx) GetRecord(key string) (r Record, err error) {
+
+	raw := t.tx.Get(rrBucket, key)
+
+	if len(raw) == 0 {
+		e := NotFoundError{Message: "Record not found, key:  " + key}
+		log.Println(e.Error())
+		return r, e
+	}
+
+	err = json.Unmarshal(raw, &r)
+	if err != nil {
+		log.Errorf("Record unmarshalling failed: %s", err.Error())
+		return r, err
+	}
+
+	log.Debugf("Record found %s", r.RR)
+	return r, nil
+}
+
+//StoreRecord save a record, returns the new ID used as version of the record
+func (t *Tx) StoreRecord(key string, record Record) (string, error) {
+
+	old, err := t.previous(key)
+	if err != nil {
+		return "", err
+	}
+
+	record.ID = genid()
+	log.Debugf("Set ID %s", record.ID)
+
+	record.Values = record.GetValues()
+	if len(record.Values) > 0 {
+		record.RR = record.Values[0].RR
+	}
+
+	val, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+
+	if err := t.tx.Put(rrBucket, key, val); err != nil {
+		return "", err
+	}
+
+	oldExpires := int64(0)
+	if old != nil {
+		oldExpires = old.Expires
+	}
+	if err := setExpiry(t.tx, key, oldExpires, record.Expires); err != nil {
+		return "", err
+	}
+
+	return record.ID, t.addChange(key, old, &record)
+}
+
+//DeleteRecord remove a record, detaching it from its lease
+func (t *Tx) DeleteRecord(key string) error {
+
+	old, err := t.previous(key)
+	if err != nil {
+		return err
+	}
+
+	if old == nil {
+		log.Debugf("Nothing to remove for %s", key)
+		return nil
+	}
+
+	if old.LeaseID != "" {
+		if err := t.removeLeaseKey(old.LeaseID, key); err != nil {
+			return err
+		}
+	}
+
+	err = t.tx.Delete(rrBucket, key)
+	if err != nil {
+		e := errors.New("Delete record failed for domain:  " + key)
+		log.Println(e.Error())
+		return e
+	}
+
+	if err := setExpiry(t.tx, key, old.Expires, 0); err != nil {
+		return err
+	}
+
+	log.Debugf("Removed %s", key)
+	return t.addChange(key, old, nil)
+}
+
+//StoreLease attach the records to a lease, creating it if needed.
+// Use a duration of 0 to keep the stored one
+func (t *Tx) StoreLease(id string, duration int64, keys ...string) error {
+
+	lease := Lease{}
+	if raw := t.tx.Get(leaseBucket, id); raw != nil {
+		if err := json.Unmarshal(raw, &lease); err != nil {
+			return err
+		}
+	}
+
+	if duration > 0 {
+		lease.Duration = duration
+	}
+	for _, key := range keys {
+		if !contains(lease.Keys, key) {
+			lease.Keys = append(lease.Keys, key)
+		}
+	}
+
+	return t.putLease(id, lease)
+}
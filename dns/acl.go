@@ -0,0 +1,180 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+//ACL list of networks allowed to perform an operation.
+// A nil ACL allows any client, an empty one refuses everybody
+type ACL []*net.IPNet
+
+// DefaultRecursionACL are the loopback and private networks, allowed to send
+// the queries forwarded to the upstream servers
+const DefaultRecursionACL = "127.0.0.0/8,::1,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7"
+
+// the ACLs are replaced on configuration reload, while serving
+var (
+	aclMu        sync.RWMutex
+	queryACL     ACL
+	updateACL    ACL
+	recursionACL ACL
+)
+
+func init() {
+	recursionACL, _ = ParseACL(DefaultRecursionACL)
+}
+
+//ParseACL parse a comma separated list of CIDR or IP addresses.
+// Use `any` (or an empty string) to allow all clients and `none` to deny all
+func ParseACL(list string) (ACL, error) {
+
+	list = strings.TrimSpace(list)
+	if list == "" || list == "any" {
+		return nil, nil
+	}
+
+	acl := ACL{}
+	if list == "none" {
+		return acl, nil
+	}
+
+	for _, item := range strings.Split(list, ",") {
+
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, errors.New("Invalid ACL address: " + item)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			acl = append(acl, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, errors.New("Invalid ACL network: " + item)
+		}
+		acl = append(acl, network)
+	}
+
+	return acl, nil
+}
+
+//Allowed check if the client address matches the ACL
+func (a ACL) Allowed(ip net.IP) bool {
+
+	if a == nil {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range a {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//SetQueryACL set the networks allowed to query the server
+func SetQueryACL(acl ACL) {
+	log.Debugf("Allow query from %s", acl)
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	queryACL = acl
+}
+
+//SetUpdateACL set the networks allowed to send dynamic updates
+func SetUpdateACL(acl ACL) {
+	log.Debugf("Allow update from %s", acl)
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	updateACL = acl
+}
+
+//SetRecursionACL set the networks allowed to send the queries forwarded to
+// the upstream servers, to not serve as open resolver
+func SetRecursionACL(acl ACL) {
+	log.Debugf("Allow recursion from %s", acl)
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	recursionACL = acl
+}
+
+// queryAllowed check if a client is allowed to query the server
+func queryAllowed(ip net.IP) bool {
+	aclMu.RLock()
+	defer aclMu.RUnlock()
+	return queryACL.Allowed(ip)
+}
+
+// recursionAllowed check if the queries of a client can be forwarded
+func recursionAllowed(ip net.IP) bool {
+	aclMu.RLock()
+	defer aclMu.RUnlock()
+	return recursionACL.Allowed(ip)
+}
+
+// updateAllowed check if a client is allowed to send dynamic updates
+func updateAllowed(ip net.IP) bool {
+	aclMu.RLock()
+	defer aclMu.RUnlock()
+	return updateACL.Allowed(ip)
+}
+
+//String return the ACL as a comma separated list
+func (a ACL) String() string {
+
+	if a == nil {
+		return "any"
+	}
+
+	if len(a) == 0 {
+		return "none"
+	}
+
+	list := make([]string, len(a))
+	for i, network := range a {
+		list[i] = network.String()
+	}
+
+	return strings.Join(list, ",")
+}
+
+//ClientIP extract the IP from a network address
+func ClientIP(addr net.Addr) net.IP {
+
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.TCPAddr:
+		return a.IP
+	case nil:
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+
+	return net.ParseIP(host)
+}
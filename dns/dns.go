@@ -1,27 +1,116 @@
 package dns
 
 import (
+	"context"
 	"errors"
 	"math"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/idna"
 
 	"github.com/miekg/dns"
+	"github.com/muka/ddns/audit"
 	"github.com/muka/ddns/db"
 )
 
-//GetKey return the reverse domain
+// Handler answer DNS queries and updates from the records of a DB
+type Handler struct {
+	db *db.DB
+	// readOnly refuse the updates, on replicas
+	readOnly bool
+}
+
+//NewHandler create a DNS handler on a DB
+func NewHandler(d *db.DB) *Handler {
+	return &Handler{db: d}
+}
+
+//SetReadOnly refuse the dynamic updates, the records are changed only by replication
+func (h *Handler) SetReadOnly(readOnly bool) {
+	h.readOnly = readOnly
+}
+
+//CanonicalName return a name lowercase and fully qualified, with the Unicode
+// labels converted to punycode (IDNA 2008, UTS 46 mapping). DNS names are
+// case-insensitive, the records are stored and compared by canonical name
+func CanonicalName(name string) (string, error) {
+
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if name == "" {
+		return "", errors.New("Invalid domain: name is empty")
+	}
+
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if label == "" {
+			return "", errors.New("Invalid domain " + name + ": empty label")
+		}
+		if !isASCII(label) || strings.HasPrefix(strings.ToLower(label), "xn--") {
+			ascii, err := idna.Lookup.ToASCII(label)
+			if err != nil {
+				return "", errors.New("Invalid domain " + name + ": label " + label + ": " + strings.TrimPrefix(err.Error(), "idna: "))
+			}
+			label = ascii
+		}
+		if len(label) > 63 {
+			return "", errors.New("Invalid domain " + name + ": label " + label + " is longer than 63 characters")
+		}
+		labels[i] = strings.ToLower(label)
+	}
+
+	name = dns.Fqdn(strings.Join(labels, "."))
+	if _, ok := dns.IsDomainName(name); !ok {
+		return "", errors.New("Invalid domain: " + name)
+	}
+
+	return name, nil
+}
+
+//UnicodeName return a canonical name with the punycode labels converted to
+// Unicode, for display. The name is returned as is if not valid
+func UnicodeName(name string) string {
+	// names are validated when stored, the labels like _dmarc are kept
+	unicode, err := idna.ToUnicode(name)
+	if err != nil {
+		return name
+	}
+	return unicode
+}
+
+// isASCII check if a name has only ASCII characters
+func isASCII(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if name[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+//GetKey return the reverse domain, of the canonical name
 func GetKey(domain string, rtype uint16) (r string, e error) {
 	log.Debugf("Get key for %s", domain)
+
+	domain, e = CanonicalName(domain)
+	if e != nil {
+		log.Error(e.Error())
+		return r, e
+	}
+
 	if n, ok := dns.IsDomainName(domain); ok {
 
 		labels := dns.SplitDomainName(domain)
 
 		// Reverse domain, starting from top-level domain
 		// eg.  ".com.mkaczanowski.test "
+		// with 4 or more labels the order is not a plain reversal, kept as is
+		// since the stored keys depend on it, see ParseKey
 		var tmp string
 		for i := 0; i < int(math.Floor(float64(n/2))); i++ {
 			tmp = labels[i]
@@ -40,26 +129,50 @@ func GetKey(domain string, rtype uint16) (r string, e error) {
 	return r, e
 }
 
-//GetRecord return a new DNS record
-func GetRecord(domain string, rtype uint16) (dns.RR, error) {
+//ParseKey return the domain and type of a record key, see GetKey
+func ParseKey(key string) (string, uint16, error) {
+
+	i := strings.LastIndex(key, "_")
+	if i < 0 {
+		return "", 0, errors.New("Invalid record key: " + key)
+	}
+
+	rtype, err := strconv.Atoi(key[i+1:])
+	if err != nil {
+		return "", 0, errors.New("Invalid record key: " + key)
+	}
+
+	// undo the swaps of GetKey, in reverse order
+	labels := strings.Split(key[:i], ".")
+	n := len(labels)
+	for l := n/2 - 1; l >= 0; l-- {
+		labels[l], labels[n-1] = labels[n-1], labels[l]
+	}
+
+	return dns.Fqdn(strings.Join(labels, ".")), uint16(rtype), nil
+}
+
+//GetRecord return the first DNS record stored for a domain
+func (h *Handler) GetRecord(domain string, rtype uint16) (dns.RR, error) {
 
 	log.Debugf("Load record %s", domain)
 
-	key, err := GetKey(domain, rtype)
+	rrs, _, err := h.GetRecordSet(domain, rtype)
 	if err != nil {
 		return nil, err
 	}
 
-	record, err := db.GetRecord(key)
-	if err == nil {
-		return dns.NewRR(record.RR)
+	if len(rrs) == 0 {
+		return nil, db.NotFoundError{Message: "Record set is empty: " + domain}
 	}
 
-	return nil, err
+	return rrs[0], nil
 }
 
-//UpdateRecord update or remove a record
-func UpdateRecord(r dns.RR, q *dns.Question) error {
+//UpdateRecord update or remove a record, the actor is stored in the record history
+func (h *Handler) UpdateRecord(r dns.RR, q *dns.Question, actor string) error {
+
+	origin := db.Origin{Actor: actor, Source: db.SourceNSUpdate}
 
 	var (
 		rr    dns.RR
@@ -70,10 +183,14 @@ func UpdateRecord(r dns.RR, q *dns.Question) error {
 	)
 
 	header := r.Header()
-	name = header.Name
 	rtype = header.Rrtype
 	ttl = header.Ttl
 
+	name, err := CanonicalName(header.Name)
+	if err != nil {
+		return err
+	}
+
 	revName, err := GetKey(name, rtype)
 
 	if err != nil {
@@ -83,37 +200,31 @@ func UpdateRecord(r dns.RR, q *dns.Question) error {
 	if _, ok := dns.IsDomainName(name); ok {
 		if header.Class == dns.ClassANY && header.Rdlength == 0 { // Delete record
 			log.Debugf("Remove %s", name)
-			db.DeleteRecord(revName)
+			if err := h.db.DeleteRecord(origin, revName); err != nil {
+				return err
+			}
+			forgetCounter(revName)
 		} else {
 
 			// Add record
 			rheader := GetHeader(name, rtype, ttl)
 
 			if a, ok := r.(*dns.A); ok {
-				rrr, err := GetRecord(name, rtype)
-				if err == nil {
-					rr = rrr.(*dns.A)
-				} else {
-					rr = new(dns.A)
-				}
 
+				rr = new(dns.A)
 				ip = a.A
 				rr.(*dns.A).Hdr = rheader
 				rr.(*dns.A).A = ip
 
 			} else if a, ok := r.(*dns.AAAA); ok {
 
-				rrr, err := GetRecord(name, rtype)
-				if err == nil {
-					rr = rrr.(*dns.AAAA)
-				} else {
-					rr = new(dns.AAAA)
-				}
-
+				rr = new(dns.AAAA)
 				ip = a.AAAA
 				rr.(*dns.AAAA).Hdr = rheader
 				rr.(*dns.AAAA).AAAA = ip
 
+			} else {
+				return errors.New("Record type not supported (Use one of A, AAAA)")
 			}
 
 			rrKey, err1 := GetKey(rr.Header().Name, rr.Header().Rrtype)
@@ -121,11 +232,31 @@ func UpdateRecord(r dns.RR, q *dns.Question) error {
 				return err1
 			}
 
-			log.Debugf("Saving record %s (%s)", rr.Header().Name, rrKey)
+			record, err1 := h.db.GetRecord(rrKey)
+			if header.Class == dns.ClassNONE { // Delete a record from the set
+				if err1 != nil {
+					return nil
+				}
+				log.Debugf("Remove %s from %s", rr.String(), name)
+				if _, err := RemoveRecordValue(&record, rr); err != nil {
+					return err
+				}
+				if len(record.Values) == 0 {
+					if err := h.db.DeleteRecord(origin, rrKey); err != nil {
+						return err
+					}
+					forgetCounter(rrKey)
+					return nil
+				}
+			} else if err1 != nil {
+				record = db.NewRecord(rr.String(), 0)
+			} else if err := AddRecordValue(&record, rr, 0); err != nil {
+				return err
+			}
 
-			record := db.NewRecord(rr.String(), 0)
-			db.StoreRecord(rrKey, record)
+			log.Debugf("Saving record %s (%s)", rr.Header().Name, rrKey)
 
+			return h.db.StoreRecord(origin, rrKey, record)
 		}
 	}
 
@@ -142,49 +273,102 @@ func GetHeader(name string, rtype uint16, ttl uint32) dns.RR_Header {
 	}
 }
 
-//AddPTRRecord for the specified domain and ip address
-func AddPTRRecord(ip string, domain string, ttl uint32, expires int64) error {
+//AddPTRRecord for the specified domain and ip address, returns the record key
+func (h *Handler) AddPTRRecord(origin db.Origin, ip string, domain string, ttl uint32, expires int64, leaseID string) (string, error) {
+
+	key, record, err := NewPTRRecord(ip, domain, ttl, expires, leaseID)
+	if err != nil {
+		return "", err
+	}
+
+	return key, h.db.StoreRecord(origin, key, record)
+}
+
+//NewPTRRecord create the PTR record of an address, returns the key to store it
+func NewPTRRecord(ip string, domain string, ttl uint32, expires int64, leaseID string) (string, db.Record, error) {
 
 	rtype := dns.TypePTR
 
+	domain, err := CanonicalName(domain)
+	if err != nil {
+		return "", db.Record{}, err
+	}
+
 	rr := new(dns.PTR)
 	rr.Ptr = ip
 	rr.Hdr = GetHeader(domain, rtype, ttl)
 
 	key, err := GetKey(domain, rtype)
 	if err != nil {
-		return err
+		return "", db.Record{}, err
 	}
 
 	log.Debugf("Adding PTR Record %s > %s", ip, domain)
 	record := db.NewRecord(rr.String(), expires)
+	record.LeaseID = leaseID
 
-	return db.StoreRecord(key, record)
+	return key, record, nil
 }
 
-func parseQuery(m *dns.Msg) bool {
-	var rr dns.RR
+func (h *Handler) parseQuery(m *dns.Msg) bool {
 	found := 0
 	for _, q := range m.Question {
 		log.Debugf("DNS query: %s", q.String())
-		readRR, e := GetRecord(q.Name, q.Qtype)
+		rrs, record, e := h.GetRecordSet(q.Name, q.Qtype)
 		if e != nil {
 			log.Debugf("Error getting record: %s", e.Error())
 			continue
 		}
-		rr = readRR.(dns.RR)
-		if rr.Header().Name == q.Name {
-			log.Debugf("Found match: %s", rr.String())
-			m.Answer = append(m.Answer, rr)
-			found++
+		key, _ := GetKey(q.Name, q.Qtype)
+		name, _ := CanonicalName(q.Name)
+		record, rrs = filterHealthy(key, record, rrs)
+		for _, rr := range ApplyPolicy(key, record, rrs) {
+			if rrName, err := CanonicalName(rr.Header().Name); err == nil && rrName == name {
+				// answer with the case of the query, randomized by some resolvers
+				rr = dns.Copy(rr)
+				rr.Header().Name = q.Name
+				log.Debugf("Found match: %s", rr.String())
+				m.Answer = append(m.Answer, rr)
+				found++
+			}
 		}
 	}
 
 	return found > 0
 }
 
-//HandleDNSRequest handle incoming requests
-func HandleDNSRequest(request *dns.Msg) *dns.Msg {
+//ServeDNS answer the requests of the DNS server, the responses to the
+// requests signed with a TSIG key are signed
+func (h *Handler) ServeDNS(w dns.ResponseWriter, request *dns.Msg) {
+
+	client := ClientIP(w.RemoteAddr())
+
+	tsig := request.IsTsig()
+	signed := false
+	if tsig != nil {
+		if err := w.TsigStatus(); err != nil {
+			log.Debugf("TSIG verification of %s failed: %s", client, err.Error())
+		} else {
+			signed = true
+		}
+	}
+
+	response := h.handleRequest(request, client, signed)
+	if signed {
+		response.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	}
+
+	w.WriteMsg(response)
+}
+
+//HandleDNSRequest handle incoming requests, the TSIG signatures are not
+// verified: when TSIG keys are set the updates are refused
+func (h *Handler) HandleDNSRequest(request *dns.Msg, client net.IP) *dns.Msg {
+	return h.handleRequest(request, client, false)
+}
+
+// handleRequest answer a request, signed is set if its TSIG signature is valid
+func (h *Handler) handleRequest(request *dns.Msg, client net.IP, signed bool) *dns.Msg {
 
 	response := new(dns.Msg)
 	response.SetReply(request)
@@ -193,45 +377,257 @@ func HandleDNSRequest(request *dns.Msg) *dns.Msg {
 	switch request.Opcode {
 	case dns.OpcodeQuery:
 
+		if !queryAllowed(client) {
+			log.Debugf("Query refused for %s", client)
+			response.SetRcode(request, dns.RcodeRefused)
+			return response
+		}
+
+		if len(request.Question) > 0 && !inZones(request.Question[0].Name) {
+			return forwardQuery(request, response, client)
+		}
+
 		response.Authoritative = true
 
 		// m.RecursionAvailable = true
 		// m.RecursionDesired = true
 
 		log.Debugf("Got query request")
-		found := parseQuery(response)
+		found := h.parseQuery(response)
+
+		// without zones, the names not found are forwarded if upstreams are set
+		if !found && !hasZones() && canForward() && recursionAllowed(client) {
+			return forwardQuery(request, response, client)
+		}
 
 		if !found {
 			// return NXDOMAIN
 			log.Debugf("Record not found")
 			response.SetRcode(request, dns.RcodeNameError)
 		}
+
+	case dns.OpcodeUpdate:
+
+		if !updateAllowed(client) {
+			log.Debugf("Update refused for %s", client)
+			audit.Log(audit.Entry{
+				ClientIP: client.String(),
+				Source:   db.SourceNSUpdate,
+				Action:   "update",
+				Target:   updateTarget(request),
+				Outcome:  audit.OutcomeDenied,
+				Error:    "Refused by ACL",
+			})
+			response.SetRcode(request, dns.RcodeRefused)
+			return response
+		}
+
+		if h.readOnly {
+			log.Debugf("Update refused for %s, read-only replica", client)
+			audit.Log(audit.Entry{
+				ClientIP: client.String(),
+				Source:   db.SourceNSUpdate,
+				Action:   "update",
+				Target:   updateTarget(request),
+				Outcome:  audit.OutcomeDenied,
+				Error:    "Read-only replica",
+			})
+			response.SetRcode(request, dns.RcodeRefused)
+			return response
+		}
+
+		if !h.db.IsLeader() {
+			log.Debugf("Update refused for %s, not the cluster leader", client)
+			audit.Log(audit.Entry{
+				ClientIP: client.String(),
+				Source:   db.SourceNSUpdate,
+				Action:   "update",
+				Target:   updateTarget(request),
+				Outcome:  audit.OutcomeDenied,
+				Error:    "Not the cluster leader",
+			})
+			response.SetRcode(request, dns.RcodeRefused)
+			return response
+		}
+
+		if tsigRequired() && !signed {
+			log.Debugf("Update refused for %s, not signed", client)
+			audit.Log(audit.Entry{
+				ClientIP: client.String(),
+				Source:   db.SourceNSUpdate,
+				Action:   "update",
+				Target:   updateTarget(request),
+				Outcome:  audit.OutcomeDenied,
+				Error:    "TSIG signature missing or invalid",
+			})
+			response.SetRcode(request, dns.RcodeNotAuth)
+			return response
+		}
+
+		for _, rr := range request.Ns {
+			if !inZones(rr.Header().Name) {
+				log.Debugf("Update refused for %s, %s is not in the served zones", client, rr.Header().Name)
+				audit.Log(audit.Entry{
+					ClientIP: client.String(),
+					Source:   db.SourceNSUpdate,
+					Action:   "update",
+					Target:   updateTarget(request),
+					Outcome:  audit.OutcomeDenied,
+					Error:    "Not in the served zones",
+				})
+				response.SetRcode(request, dns.RcodeNotZone)
+				return response
+			}
+		}
+
+		// identify the client by TSIG key, if signed
+		actor := client.String()
+		if signed {
+			actor = request.IsTsig().Hdr.Name
+		}
+
+		log.Debugf("Got update request")
+		for _, question := range request.Question {
+			for _, rr := range request.Ns {
+				err := h.UpdateRecord(rr, &question, actor)
+
+				entry := audit.Entry{
+					Actor:    actor,
+					ClientIP: client.String(),
+					Source:   db.SourceNSUpdate,
+					Action:   "update",
+					Target:   rr.String(),
+					Outcome:  audit.OutcomeSuccess,
+				}
+				if err != nil {
+					entry.Outcome = audit.OutcomeFailure
+					entry.Error = err.Error()
+				}
+				audit.Log(entry)
+
+				if err != nil {
+					log.Errorf("Update failed: %s", err.Error())
+					response.SetRcode(request, dns.RcodeServerFailure)
+					return response
+				}
+			}
+		}
 	}
 
 	return response
 }
 
-//Serve the DNS server
-func Serve(ip string, port int) error {
+// forwardQuery answer a query from the upstream servers, refused if not set
+// or if the client is not allowed to recurse
+func forwardQuery(request *dns.Msg, response *dns.Msg, client net.IP) *dns.Msg {
 
-	log.Debugf("Starting server on %s:%d", ip, port)
-	server := &dns.Server{Addr: ip + ":" + strconv.Itoa(port), Net: "udp"}
+	if !canForward() {
+		log.Debugf("Query of %s refused, not in the served zones", request.Question[0].Name)
+		response.SetRcode(request, dns.RcodeRefused)
+		return response
+	}
 
-	err := server.ListenAndServe()
-	defer server.Shutdown()
+	if !recursionAllowed(client) {
+		log.Debugf("Query of %s refused for %s, recursion not allowed", request.Question[0].Name, client)
+		response.SetRcode(request, dns.RcodeRefused)
+		return response
+	}
 
+	// the signature of the client is not valid for the upstream servers
+	query := request.Copy()
+	if tsig := query.IsTsig(); tsig != nil {
+		query.Extra = query.Extra[:len(query.Extra)-1]
+	}
+
+	answer, err := forward(query)
 	if err != nil {
-		log.Fatalf("Failed to setup the udp server: %s", err.Error())
+		log.Warnf("Failed to forward the query of %s: %s", request.Question[0].Name, err.Error())
+		response.SetRcode(request, dns.RcodeServerFailure)
+		return response
+	}
+
+	answer.Id = request.Id
+	return answer
+}
+
+// updateTarget describe the records of an update request
+func updateTarget(request *dns.Msg) string {
+	targets := make([]string, 0, len(request.Ns))
+	for _, rr := range request.Ns {
+		targets = append(targets, rr.String())
+	}
+	return strings.Join(targets, ", ")
+}
+
+// acceptMsg accept the dynamic updates, rejected by the default of the server
+func acceptMsg(dh dns.Header) dns.MsgAcceptAction {
+	isResponse := dh.Bits&(1<<15) != 0
+	if opcode := int(dh.Bits>>11) & 0xF; opcode == dns.OpcodeUpdate && !isResponse {
+		return dns.MsgAccept
 	}
+	return dns.DefaultMsgAcceptFunc(dh)
+}
 
-	return err
+//Server serve the DNS over UDP
+type Server struct {
+	server  *dns.Server
+	started chan struct{}
+	once    sync.Once
+}
+
+//Listen bind the DNS server, serve the requests with Serve
+func Listen(ip string, port int, handler dns.Handler) (*Server, error) {
+
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	log.Debugf("Starting server on %s", address)
+
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{started: make(chan struct{})}
+	s.server = &dns.Server{
+		PacketConn:        conn,
+		Handler:           handler,
+		TsigProvider:      TSIGProvider{},
+		MsgAcceptFunc:     acceptMsg,
+		NotifyStartedFunc: s.notifyStarted,
+	}
+
+	return s, nil
+}
+
+// notifyStarted unblock Shutdown, also when the server fails to start
+func (s *Server) notifyStarted() {
+	s.once.Do(func() {
+		close(s.started)
+	})
+}
+
+//Serve the requests until shut down
+func (s *Server) Serve() error {
+	defer s.notifyStarted()
+	return s.server.ActivateAndServe()
+}
+
+//Shutdown stop reading requests and wait the in-flight ones until the context is done
+func (s *Server) Shutdown(ctx context.Context) error {
+
+	select {
+	case <-s.started:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return s.server.ShutdownContext(ctx)
 }
 
 //RemoveExpired Check for expired record and remove them
-func RemoveExpired() {
+func (h *Handler) RemoveExpired() {
 
 	// log.Debug("Checking expired records")
-	list, err := db.GetExpiredRecords()
+	list, err := h.db.GetExpiredRecords()
 
 	if err != nil {
 		log.Errorf("Failed to list expired values: %s", err.Error())
@@ -244,11 +640,32 @@ func RemoveExpired() {
 		return
 	}
 
+	removed := 0
 	for i := 0; i < ll; i++ {
-		db.DeleteRecord(list[i])
+		// the record is checked again, it may have been renewed meanwhile
+		deleted, err := h.db.DeleteExpired(db.Origin{Source: db.SourceExpiry}, list[i])
+		if err == nil && !deleted {
+			continue
+		}
+		if deleted {
+			removed++
+			forgetCounter(list[i])
+		}
+
+		entry := audit.Entry{
+			Source:  db.SourceExpiry,
+			Action:  "expire",
+			Target:  list[i],
+			Outcome: audit.OutcomeSuccess,
+		}
+		if err != nil {
+			entry.Outcome = audit.OutcomeFailure
+			entry.Error = err.Error()
+		}
+		audit.Log(entry)
 	}
 
-	if ll > 0 {
-		log.Debugf("Removed %d expired records", ll)
+	if removed > 0 {
+		log.Debugf("Removed %d expired records", removed)
 	}
 }
@@ -0,0 +1,107 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestParseKeyRoundTrip(t *testing.T) {
+
+	domains := []string{
+		"com.",
+		"example.com.",
+		"host.example.com.",
+		"www.host.example.com.",
+		"a.b.c.d.e.example.com.",
+		"_dmarc.mail.example.co.uk.",
+		"10.1.168.192.in-addr.arpa.",
+	}
+
+	for _, domain := range domains {
+		for _, rtype := range []uint16{dns.TypeA, dns.TypeTXT} {
+
+			key, err := GetKey(domain, rtype)
+			if err != nil {
+				t.Fatalf("GetKey(%s): %s", domain, err)
+			}
+
+			parsed, parsedType, err := ParseKey(key)
+			if err != nil {
+				t.Fatalf("ParseKey(%s): %s", key, err)
+			}
+			if parsed != domain || parsedType != rtype {
+				t.Errorf("ParseKey(%s) = %s %d, want %s %d", key, parsed, parsedType, domain, rtype)
+			}
+		}
+	}
+}
+
+func TestGetKeyStoredOrder(t *testing.T) {
+
+	// the keys of the existing databases depend on this order
+	keys := map[string]string{
+		"example.com.":          "com.example_1",
+		"host.example.com.":     "com.example.host_1",
+		"www.host.example.com.": "com.www.example.host_1",
+	}
+
+	for domain, want := range keys {
+		key, err := GetKey(domain, dns.TypeA)
+		if err != nil {
+			t.Fatalf("GetKey(%s): %s", domain, err)
+		}
+		if key != want {
+			t.Errorf("GetKey(%s) = %s, want %s", domain, key, want)
+		}
+	}
+}
+
+func TestParseKeyInvalid(t *testing.T) {
+	for _, key := range []string{"com.example", "com.example_A"} {
+		if _, _, err := ParseKey(key); err == nil {
+			t.Errorf("ParseKey(%s) should fail", key)
+		}
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+
+	names := map[string]string{
+		"Foo.Example.COM":           "foo.example.com.",
+		" foo.example.com. ":        "foo.example.com.",
+		"_dmarc.Example.com.":       "_dmarc.example.com.",
+		"Bücher.example.com":        "xn--bcher-kva.example.com.",
+		"XN--BCHER-KVA.example.com": "xn--bcher-kva.example.com.",
+	}
+
+	for name, want := range names {
+		canonical, err := CanonicalName(name)
+		if err != nil {
+			t.Fatalf("CanonicalName(%s): %s", name, err)
+		}
+		if canonical != want {
+			t.Errorf("CanonicalName(%s) = %s, want %s", name, canonical, want)
+		}
+	}
+
+	for _, name := range []string{"", ".", "foo..example.com", "xn--a.example.com"} {
+		if _, err := CanonicalName(name); err == nil {
+			t.Errorf("CanonicalName(%q) should fail", name)
+		}
+	}
+}
+
+func TestGetKeyCanonical(t *testing.T) {
+
+	// the names differing by case or encoding are stored by the same key
+	key, err := GetKey("foo.example.com.", dns.TypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"FOO.Example.com", "Foo.example.com."} {
+		if k, err := GetKey(name, dns.TypeA); err != nil || k != key {
+			t.Errorf("GetKey(%s) = %s, want %s", name, k, key)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultForwardTimeout of a query sent to an upstream server
+const defaultForwardTimeout = 2 * time.Second
+
+// the zones and the upstream servers are replaced on configuration reload,
+// while serving
+var (
+	forwardMu      sync.RWMutex
+	zones          []string
+	upstreams      []string
+	forwardTimeout = defaultForwardTimeout
+)
+
+//SetZones set the zones served from the records. When set, the queries of
+// other names are forwarded and their updates refused
+func SetZones(list []string) error {
+
+	canonical := make([]string, 0, len(list))
+	for _, zone := range list {
+		name, err := CanonicalName(zone)
+		if err != nil {
+			return err
+		}
+		canonical = append(canonical, name)
+	}
+
+	log.Debugf("Serving zones %s", strings.Join(canonical, ", "))
+
+	forwardMu.Lock()
+	defer forwardMu.Unlock()
+	zones = canonical
+
+	return nil
+}
+
+//ParseUpstream return the address of an upstream server, on port 53 if not set
+func ParseUpstream(upstream string) (string, error) {
+
+	upstream = strings.TrimSpace(upstream)
+	if net.ParseIP(upstream) != nil {
+		return net.JoinHostPort(upstream, "53"), nil
+	}
+
+	host, port, err := net.SplitHostPort(upstream)
+	if err != nil || host == "" || port == "" {
+		return "", errors.New("Invalid upstream server, use ip or ip:port: " + upstream)
+	}
+
+	return upstream, nil
+}
+
+//SetForwarders set the upstream servers of the queries not served from the
+// records, tried in order. A timeout of 0 use the default
+func SetForwarders(list []string, timeout time.Duration) error {
+
+	servers := make([]string, 0, len(list))
+	for _, upstream := range list {
+		address, err := ParseUpstream(upstream)
+		if err != nil {
+			return err
+		}
+		servers = append(servers, address)
+	}
+
+	if timeout <= 0 {
+		timeout = defaultForwardTimeout
+	}
+
+	log.Debugf("Forwarding to %s", strings.Join(servers, ", "))
+
+	forwardMu.Lock()
+	defer forwardMu.Unlock()
+	upstreams = servers
+	forwardTimeout = timeout
+
+	return nil
+}
+
+// inZones check if a name belongs to the served zones, any name if no zone is set
+func inZones(name string) bool {
+
+	name, err := CanonicalName(name)
+	if err != nil {
+		return false
+	}
+
+	forwardMu.RLock()
+	defer forwardMu.RUnlock()
+
+	if len(zones) == 0 {
+		return true
+	}
+
+	for _, zone := range zones {
+		if dns.IsSubDomain(zone, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasZones check if the served zones are set
+func hasZones() bool {
+	forwardMu.RLock()
+	defer forwardMu.RUnlock()
+	return len(zones) > 0
+}
+
+// canForward check if upstream servers are set
+func canForward() bool {
+	forwardMu.RLock()
+	defer forwardMu.RUnlock()
+	return len(upstreams) > 0
+}
+
+// forward send a query to the upstream servers, returns the first answer
+func forward(request *dns.Msg) (*dns.Msg, error) {
+
+	forwardMu.RLock()
+	servers := upstreams
+	timeout := forwardTimeout
+	forwardMu.RUnlock()
+
+	if len(servers) == 0 {
+		return nil, errors.New("No upstream server")
+	}
+
+	client := &dns.Client{Net: "udp", Timeout: timeout}
+
+	var err error
+	for _, server := range servers {
+
+		var response *dns.Msg
+		response, _, err = client.Exchange(request, server)
+		if err == nil && response.Truncated {
+			// retry over TCP to get the whole answer
+			response, _, err = (&dns.Client{Net: "tcp", Timeout: timeout}).Exchange(request, server)
+		}
+		if err == nil {
+			return response, nil
+		}
+
+		log.Debugf("Forwarding to %s failed: %s", server, err.Error())
+	}
+
+	return nil, err
+}
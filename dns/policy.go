@@ -0,0 +1,268 @@
+package dns
+
+import (
+	"container/list"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/muka/ddns/db"
+	"github.com/muka/ddns/health"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	//PolicyFixed answer with the records in the stored order
+	PolicyFixed = "fixed"
+	//PolicyRoundRobin rotate the records on each query
+	PolicyRoundRobin = "round-robin"
+	//PolicyRandom shuffle the records on each query
+	PolicyRandom = "random"
+	//PolicyWeighted shuffle the records according to their weight
+	PolicyWeighted = "weighted"
+)
+
+const defaultWeight = 1
+
+// maxCounters is the number of record sets with a round-robin position kept,
+// the least recently queried are dropped and restart from the first record
+const maxCounters = 10000
+
+// rrCounter is the next round-robin position of a record set
+type rrCounter struct {
+	key  string
+	next int
+}
+
+var (
+	rrCounters   = make(map[string]*list.Element)
+	rrOrder      = list.New()
+	rrCountersMu sync.Mutex
+
+	random   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	randomMu sync.Mutex
+)
+
+//ParsePolicy validate an answer policy name
+func ParsePolicy(policy string) (string, error) {
+	p := strings.ToLower(strings.TrimSpace(policy))
+	switch p {
+	case "":
+		return PolicyFixed, nil
+	case PolicyFixed, PolicyRoundRobin, PolicyRandom, PolicyWeighted:
+		return p, nil
+	}
+	return "", errors.New("Policy not supported (Use one of fixed, round-robin, random, weighted)")
+}
+
+//GetRecordSet return the records stored for a domain
+func (h *Handler) GetRecordSet(domain string, rtype uint16) ([]dns.RR, db.Record, error) {
+
+	key, err := GetKey(domain, rtype)
+	if err != nil {
+		return nil, db.Record{}, err
+	}
+
+	record, err := h.db.GetRecord(key)
+	if err != nil {
+		return nil, record, err
+	}
+
+	rrs, err := parseValues(record.GetValues())
+	return rrs, record, err
+}
+
+//SameRecord check if two records have the same name, type and data, ignoring the TTL
+func SameRecord(a dns.RR, b dns.RR) bool {
+	a = dns.Copy(a)
+	b = dns.Copy(b)
+	a.Header().Name = dns.Fqdn(strings.ToLower(a.Header().Name))
+	b.Header().Name = dns.Fqdn(strings.ToLower(b.Header().Name))
+	return dns.IsDuplicate(a, b)
+}
+
+//AddRecordValue add a record to the set, replacing a duplicate if found
+func AddRecordValue(record *db.Record, rr dns.RR, weight int) error {
+
+	values := record.GetValues()
+	rrs, err := parseValues(values)
+	if err != nil {
+		return err
+	}
+
+	value := db.Value{RR: rr.String(), Weight: weight}
+	for i, r := range rrs {
+		if SameRecord(r, rr) {
+			values[i] = value
+			record.Values = values
+			return nil
+		}
+	}
+
+	record.Values = append(values, value)
+	return nil
+}
+
+//RemoveRecordValue remove a record from the set, returns false if not found
+func RemoveRecordValue(record *db.Record, rr dns.RR) (bool, error) {
+
+	values := record.GetValues()
+	rrs, err := parseValues(values)
+	if err != nil {
+		return false, err
+	}
+
+	for i, r := range rrs {
+		if SameRecord(r, rr) {
+			record.Values = append(values[:i:i], values[i+1:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// nextOffset return the round-robin position of a record set and advance it
+func nextOffset(key string, size int) int {
+
+	rrCountersMu.Lock()
+	defer rrCountersMu.Unlock()
+
+	if el, ok := rrCounters[key]; ok {
+		rrOrder.MoveToFront(el)
+		counter := el.Value.(*rrCounter)
+		offset := counter.next % size
+		counter.next = offset + 1
+		return offset
+	}
+
+	if rrOrder.Len() >= maxCounters {
+		oldest := rrOrder.Back()
+		rrOrder.Remove(oldest)
+		delete(rrCounters, oldest.Value.(*rrCounter).key)
+	}
+
+	rrCounters[key] = rrOrder.PushFront(&rrCounter{key: key, next: 1})
+	return 0
+}
+
+// forgetCounter drop the round-robin position of a removed record set
+func forgetCounter(key string) {
+
+	rrCountersMu.Lock()
+	defer rrCountersMu.Unlock()
+
+	if el, ok := rrCounters[key]; ok {
+		rrOrder.Remove(el)
+		delete(rrCounters, key)
+	}
+}
+
+//ApplyPolicy sort the records of a set according to its answer policy
+func ApplyPolicy(key string, record db.Record, rrs []dns.RR) []dns.RR {
+
+	if len(rrs) < 2 {
+		return rrs
+	}
+
+	values := record.GetValues()
+	ordered := make([]dns.RR, len(rrs))
+
+	switch record.Policy {
+	case PolicyRoundRobin:
+
+		offset := nextOffset(key, len(rrs))
+
+		for i := range rrs {
+			ordered[i] = rrs[(i+offset)%len(rrs)]
+		}
+
+	case PolicyRandom:
+
+		randomMu.Lock()
+		perm := random.Perm(len(rrs))
+		randomMu.Unlock()
+
+		for i, j := range perm {
+			ordered[i] = rrs[j]
+		}
+
+	case PolicyWeighted:
+
+		// weighted random sampling without replacement (Efraimidis-Spirakis),
+		// records without a weight count as defaultWeight
+		type weighted struct {
+			rr  dns.RR
+			key float64
+		}
+
+		list := make([]weighted, len(rrs))
+		randomMu.Lock()
+		for i, rr := range rrs {
+			weight := defaultWeight
+			if i < len(values) && values[i].Weight > 0 {
+				weight = values[i].Weight
+			}
+			list[i] = weighted{rr, math.Pow(random.Float64(), 1/float64(weight))}
+		}
+		randomMu.Unlock()
+
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].key > list[j].key
+		})
+
+		for i, w := range list {
+			ordered[i] = w.rr
+		}
+
+	default:
+		copy(ordered, rrs)
+	}
+
+	log.Debugf("Applied %s policy to %s", record.Policy, key)
+	return ordered
+}
+
+// filterHealthy withhold the values failing their health check,
+// all the values are returned if none of them is healthy
+func filterHealthy(key string, record db.Record, rrs []dns.RR) (db.Record, []dns.RR) {
+
+	if record.Check == nil {
+		return record, rrs
+	}
+
+	values := record.GetValues()
+	healthyValues := make([]db.Value, 0, len(values))
+	healthyRRs := make([]dns.RR, 0, len(rrs))
+	for i, value := range values {
+		if i < len(rrs) && health.IsHealthy(key, value.RR) {
+			healthyValues = append(healthyValues, value)
+			healthyRRs = append(healthyRRs, rrs[i])
+		}
+	}
+
+	if len(healthyRRs) == 0 {
+		log.Debugf("No healthy records for %s, answering with all of them", key)
+		return record, rrs
+	}
+
+	record.Values = healthyValues
+	return record, healthyRRs
+}
+
+func parseValues(values []db.Value) ([]dns.RR, error) {
+	rrs := make([]dns.RR, 0, len(values))
+	for _, value := range values {
+		rr, err := dns.NewRR(value.RR)
+		if err != nil {
+			return nil, err
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs, nil
+}
@@ -0,0 +1,45 @@
+package dns
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNextOffset(t *testing.T) {
+
+	for i, want := range []int{0, 1, 2, 0, 1} {
+		if offset := nextOffset("com.example_1", 3); offset != want {
+			t.Errorf("Query %d offset is %d, want %d", i, offset, want)
+		}
+	}
+
+	forgetCounter("com.example_1")
+	if offset := nextOffset("com.example_1", 3); offset != 0 {
+		t.Errorf("Offset after forgetCounter is %d, want 0", offset)
+	}
+	forgetCounter("com.example_1")
+}
+
+func TestNextOffsetBounded(t *testing.T) {
+
+	nextOffset("com.example.hot_1", 2)
+	for i := 0; i < maxCounters*2; i++ {
+		nextOffset("com.example.host"+strconv.Itoa(i)+"_1", 2)
+		if i%100 == 0 {
+			// the queried record sets are kept
+			nextOffset("com.example.hot_1", 2)
+		}
+	}
+
+	rrCountersMu.Lock()
+	size := len(rrCounters)
+	_, hot := rrCounters["com.example.hot_1"]
+	rrCountersMu.Unlock()
+
+	if size > maxCounters || rrOrder.Len() != size {
+		t.Errorf("Counters grew to %d, max %d", size, maxCounters)
+	}
+	if !hot {
+		t.Errorf("Recently queried counter dropped")
+	}
+}
@@ -0,0 +1,146 @@
+package dns
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	log "github.com/sirupsen/logrus"
+)
+
+// TSIGKey shared secret of the clients signing their requests
+type TSIGKey struct {
+	Name      string
+	Algorithm string
+	// Secret encoded in base64, as generated by tsig-keygen
+	Secret string
+}
+
+// tsigAlgorithms supported to sign the requests
+var tsigAlgorithms = map[string]func() hash.Hash{
+	dns.HmacSHA1:   sha1.New,
+	dns.HmacSHA224: sha256.New224,
+	dns.HmacSHA256: sha256.New,
+	dns.HmacSHA384: sha512.New384,
+	dns.HmacSHA512: sha512.New,
+}
+
+// tsigSecret is a decoded key, by canonical name
+type tsigSecret struct {
+	algorithm string
+	secret    []byte
+}
+
+// the keys are replaced on configuration reload, while serving
+var (
+	tsigMu   sync.RWMutex
+	tsigKeys map[string]tsigSecret
+)
+
+//ParseTSIGKey validate a key, returns it with the name and algorithm canonical
+func ParseTSIGKey(key TSIGKey) (TSIGKey, error) {
+
+	name, err := CanonicalName(key.Name)
+	if err != nil {
+		return key, errors.New("Invalid TSIG key name: " + err.Error())
+	}
+	key.Name = name
+
+	algorithm := dns.Fqdn(strings.ToLower(strings.TrimSpace(key.Algorithm)))
+	if algorithm == "." {
+		algorithm = dns.HmacSHA256
+	}
+	if _, ok := tsigAlgorithms[algorithm]; !ok {
+		return key, errors.New("TSIG algorithm not supported (Use one of hmac-sha1, hmac-sha224, hmac-sha256, hmac-sha384, hmac-sha512): " + key.Algorithm)
+	}
+	key.Algorithm = algorithm
+
+	secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key.Secret))
+	if err != nil || len(secret) == 0 {
+		return key, errors.New("TSIG secret of " + name + " must be encoded in base64")
+	}
+
+	return key, nil
+}
+
+//SetTSIGKeys set the keys of the clients. When set, the dynamic updates must
+// be signed by one of the keys
+func SetTSIGKeys(keys []TSIGKey) error {
+
+	secrets := make(map[string]tsigSecret)
+	for _, key := range keys {
+		key, err := ParseTSIGKey(key)
+		if err != nil {
+			return err
+		}
+		secret, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(key.Secret))
+		secrets[key.Name] = tsigSecret{algorithm: key.Algorithm, secret: secret}
+	}
+
+	log.Debugf("Loaded %d TSIG keys", len(secrets))
+
+	tsigMu.Lock()
+	defer tsigMu.Unlock()
+	tsigKeys = secrets
+
+	return nil
+}
+
+// tsigRequired check if the updates must be signed
+func tsigRequired() bool {
+	tsigMu.RLock()
+	defer tsigMu.RUnlock()
+	return len(tsigKeys) > 0
+}
+
+//TSIGProvider sign and verify the messages with the configured keys
+type TSIGProvider struct{}
+
+// Generate return the MAC of a message signed by a key
+func (TSIGProvider) Generate(msg []byte, t *dns.TSIG) ([]byte, error) {
+
+	name, err := CanonicalName(t.Hdr.Name)
+	if err != nil {
+		return nil, dns.ErrSecret
+	}
+
+	tsigMu.RLock()
+	key, ok := tsigKeys[name]
+	tsigMu.RUnlock()
+
+	if !ok || key.algorithm != strings.ToLower(t.Algorithm) {
+		return nil, dns.ErrSecret
+	}
+
+	h := hmac.New(tsigAlgorithms[key.algorithm], key.secret)
+	h.Write(msg)
+	return h.Sum(nil), nil
+}
+
+// Verify check the MAC of a message, the time is checked by the server
+func (p TSIGProvider) Verify(msg []byte, t *dns.TSIG) error {
+
+	expected, err := p.Generate(msg, t)
+	if err != nil {
+		return err
+	}
+
+	mac, err := hex.DecodeString(t.MAC)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(mac, expected) {
+		return dns.ErrSig
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/muka/ddns/db"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	//CheckTCP connect to the target port
+	CheckTCP = "tcp"
+	//CheckHTTP send a GET request to the target and compare the response status
+	CheckHTTP = "http"
+	//CheckNone disable the health check of a record set
+	CheckNone = "none"
+)
+
+const (
+	defaultTimeout   = 2
+	defaultStatus    = http.StatusOK
+	defaultThreshold = 1
+)
+
+// Status of a single record value
+type Status struct {
+	Healthy   bool
+	LastCheck time.Time
+	Error     string
+	Failures  int
+}
+
+var (
+	states   = make(map[string]map[string]*Status)
+	statesMu sync.RWMutex
+)
+
+//ParseCheck validate a health check configuration, returns nil if the check is disabled
+func ParseCheck(check db.HealthCheck) (*db.HealthCheck, error) {
+
+	check.Type = strings.ToLower(strings.TrimSpace(check.Type))
+
+	switch check.Type {
+	case "", CheckNone:
+		return nil, nil
+	case CheckTCP, CheckHTTP:
+	default:
+		return nil, errors.New("Health check not supported (Use one of tcp, http, none)")
+	}
+
+	if check.Port <= 0 || check.Port > 65535 {
+		return nil, errors.New("Health check port is not valid")
+	}
+
+	if check.Type == CheckHTTP && !strings.HasPrefix(check.Path, "/") {
+		check.Path = "/" + check.Path
+	}
+
+	if check.Timeout < 0 || check.Threshold < 0 || check.Status < 0 {
+		return nil, errors.New("Health check values cannot be negative")
+	}
+
+	return &check, nil
+}
+
+//IsHealthy return false if the value of a record set failed its health check
+func IsHealthy(key string, rr string) bool {
+	status, ok := GetStatus(key, rr)
+	return !ok || status.Healthy
+}
+
+//GetStatus return the health status of a value, if checked
+func GetStatus(key string, rr string) (Status, bool) {
+	statesMu.RLock()
+	defer statesMu.RUnlock()
+
+	if values, ok := states[key]; ok {
+		if status, ok := values[rr]; ok {
+			return *status, true
+		}
+	}
+
+	return Status{}, false
+}
+
+//Run check the health of the records every interval, until the context is done
+func Run(ctx context.Context, d *db.DB, interval time.Duration) error {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	CheckAll(d)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			CheckAll(d)
+		}
+	}
+}
+
+//CheckAll run the health checks of all the stored records
+func CheckAll(d *db.DB) {
+
+	records, err := d.GetRecords()
+	if err != nil {
+		log.Errorf("Failed to load records for health check: %s", err.Error())
+		return
+	}
+
+	var (
+		wg      sync.WaitGroup
+		checked = make(map[string]map[string]bool)
+	)
+
+	for key, record := range records {
+
+		if record.Check == nil {
+			continue
+		}
+
+		checked[key] = make(map[string]bool)
+		for _, value := range record.GetValues() {
+			checked[key][value.RR] = true
+			wg.Add(1)
+			go func(key string, rr string, check db.HealthCheck) {
+				defer wg.Done()
+				update(key, rr, check, probe(rr, check))
+			}(key, value.RR, *record.Check)
+		}
+	}
+
+	wg.Wait()
+
+	// drop the status of removed records and values
+	statesMu.Lock()
+	for key, values := range states {
+		for rr := range values {
+			if !checked[key][rr] {
+				delete(values, rr)
+			}
+		}
+		if len(values) == 0 {
+			delete(states, key)
+		}
+	}
+	statesMu.Unlock()
+}
+
+func update(key string, rr string, check db.HealthCheck, err error) {
+
+	threshold := check.Threshold
+	if threshold == 0 {
+		threshold = defaultThreshold
+	}
+
+	statesMu.Lock()
+	defer statesMu.Unlock()
+
+	if _, ok := states[key]; !ok {
+		states[key] = make(map[string]*Status)
+	}
+
+	status, ok := states[key][rr]
+	if !ok {
+		status = &Status{Healthy: true}
+		states[key][rr] = status
+	}
+
+	status.LastCheck = time.Now()
+
+	if err == nil {
+		if !status.Healthy {
+			log.Infof("Record %s is healthy", rr)
+		}
+		status.Healthy = true
+		status.Error = ""
+		status.Failures = 0
+		return
+	}
+
+	status.Error = err.Error()
+	status.Failures++
+	if status.Failures >= threshold && status.Healthy {
+		log.Infof("Record %s is unhealthy: %s", rr, err.Error())
+		status.Healthy = false
+	}
+}
+
+func probe(value string, check db.HealthCheck) error {
+
+	rr, err := dns.NewRR(value)
+	if err != nil {
+		return err
+	}
+
+	var host string
+	switch r := rr.(type) {
+	case *dns.A:
+		host = r.A.String()
+	case *dns.AAAA:
+		host = r.AAAA.String()
+	case *dns.CNAME:
+		host = strings.TrimSuffix(r.Target, ".")
+	case *dns.MX:
+		host = strings.TrimSuffix(r.Mx, ".")
+	default:
+		return fmt.Errorf("Health check not supported for %s records", dns.TypeToString[rr.Header().Rrtype])
+	}
+
+	timeout := time.Duration(check.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = defaultTimeout * time.Second
+	}
+
+	address := net.JoinHostPort(host, strconv.Itoa(check.Port))
+
+	switch check.Type {
+	case CheckTCP:
+
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+
+	case CheckHTTP:
+
+		req, err := http.NewRequest(http.MethodGet, "http://"+address+check.Path, nil)
+		if err != nil {
+			return err
+		}
+		// allow virtual hosts to match the record name
+		req.Host = strings.TrimSuffix(rr.Header().Name, ".")
+
+		client := &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		status := check.Status
+		if status == 0 {
+			status = defaultStatus
+		}
+
+		if res.StatusCode != status {
+			return fmt.Errorf("Unexpected HTTP status %d", res.StatusCode)
+		}
+
+		return nil
+	}
+
+	return errors.New("Health check not supported: " + check.Type)
+}
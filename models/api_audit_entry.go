@@ -0,0 +1,74 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIAuditEntry Entry of the audit log
+// swagger:model apiAuditEntry
+type APIAuditEntry struct {
+
+	// action
+	Action string `json:"action,omitempty"`
+
+	// actor
+	Actor string `json:"actor,omitempty"`
+
+	// client ip
+	ClientIP string `json:"client_ip,omitempty"`
+
+	// error
+	Error string `json:"error,omitempty"`
+
+	// hash
+	Hash string `json:"hash,omitempty"`
+
+	// One of success, failure, denied
+	Outcome string `json:"outcome,omitempty"`
+
+	// Hash of the previous entry
+	Prev string `json:"prev,omitempty"`
+
+	// One of api, nsupdate, expiry
+	Source string `json:"source,omitempty"`
+
+	// target
+	Target string `json:"target,omitempty"`
+
+	// Time in RFC 3339 format
+	Time string `json:"time,omitempty"`
+}
+
+// Validate validates this api audit entry
+func (m *APIAuditEntry) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIAuditEntry) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIAuditEntry) UnmarshalBinary(b []byte) error {
+	var res APIAuditEntry
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
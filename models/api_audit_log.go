@@ -0,0 +1,84 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"strconv"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIAuditLog api audit log
+// swagger:model apiAuditLog
+type APIAuditLog struct {
+
+	// entries
+	Entries []*APIAuditEntry `json:"entries,omitempty"`
+
+	// error
+	Error string `json:"error,omitempty"`
+
+	// False if the hash chain is broken
+	Valid bool `json:"valid,omitempty"`
+}
+
+// Validate validates this api audit log
+func (m *APIAuditLog) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateEntries(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *APIAuditLog) validateEntries(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Entries) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Entries); i++ {
+		if swag.IsZero(m.Entries[i]) { // not required
+			continue
+		}
+
+		if m.Entries[i] != nil {
+			if err := m.Entries[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("entries" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIAuditLog) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIAuditLog) UnmarshalBinary(b []byte) error {
+	var res APIAuditLog
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,47 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIBackupChunk Part of a database snapshot
+// swagger:model apiBackupChunk
+type APIBackupChunk struct {
+
+	// data
+	Data strfmt.Base64 `json:"data,omitempty"`
+}
+
+// Validate validates this api backup chunk
+func (m *APIBackupChunk) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIBackupChunk) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIBackupChunk) UnmarshalBinary(b []byte) error {
+	var res APIBackupChunk
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
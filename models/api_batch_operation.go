@@ -0,0 +1,72 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIBatchOperation Change of a batch
+// swagger:model apiBatchOperation
+type APIBatchOperation struct {
+
+	// One of upsert, delete
+	Op string `json:"op,omitempty"`
+
+	// record
+	Record *APIRecord `json:"record,omitempty"`
+}
+
+// Validate validates this api batch operation
+func (m *APIBatchOperation) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateRecord(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *APIBatchOperation) validateRecord(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Record) { // not required
+		return nil
+	}
+
+	if m.Record != nil {
+		if err := m.Record.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("record")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIBatchOperation) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIBatchOperation) UnmarshalBinary(b []byte) error {
+	var res APIBatchOperation
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,110 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"strconv"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIBatchRequest api batch request
+// swagger:model apiBatchRequest
+type APIBatchRequest struct {
+
+	// operations
+	Operations []*APIBatchOperation `json:"operations,omitempty"`
+
+	// prerequisites
+	Prerequisites []*APIPrerequisite `json:"prerequisites,omitempty"`
+}
+
+// Validate validates this api batch request
+func (m *APIBatchRequest) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateOperations(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validatePrerequisites(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *APIBatchRequest) validateOperations(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Operations) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Operations); i++ {
+		if swag.IsZero(m.Operations[i]) { // not required
+			continue
+		}
+
+		if m.Operations[i] != nil {
+			if err := m.Operations[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("operations" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *APIBatchRequest) validatePrerequisites(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Prerequisites) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Prerequisites); i++ {
+		if swag.IsZero(m.Prerequisites[i]) { // not required
+			continue
+		}
+
+		if m.Prerequisites[i] != nil {
+			if err := m.Prerequisites[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("prerequisites" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIBatchRequest) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIBatchRequest) UnmarshalBinary(b []byte) error {
+	var res APIBatchRequest
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,78 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIBatchResult Result of a batch operation
+// swagger:model apiBatchResult
+type APIBatchResult struct {
+
+	// Failure of the operation, the batch is not applied
+	Error string `json:"error,omitempty"`
+
+	// index
+	Index int32 `json:"index,omitempty"`
+
+	// op
+	Op string `json:"op,omitempty"`
+
+	// record
+	Record *APIRecord `json:"record,omitempty"`
+}
+
+// Validate validates this api batch result
+func (m *APIBatchResult) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateRecord(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *APIBatchResult) validateRecord(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Record) { // not required
+		return nil
+	}
+
+	if m.Record != nil {
+		if err := m.Record.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("record")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIBatchResult) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIBatchResult) UnmarshalBinary(b []byte) error {
+	var res APIBatchResult
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
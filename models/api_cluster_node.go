@@ -0,0 +1,59 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIClusterNode Node of the Raft cluster
+// swagger:model apiClusterNode
+type APIClusterNode struct {
+
+	// Raft address of the node, host:port
+	Address string `json:"address,omitempty"`
+
+	// gRPC API address of the node, where the writes are forwarded when it is the leader
+	APIAddress string `json:"api_address,omitempty"`
+
+	// Unique ID of the node
+	ID string `json:"id,omitempty"`
+
+	// True if the node is the leader
+	Leader bool `json:"leader,omitempty"`
+
+	// True if the node votes in the elections
+	Voter bool `json:"voter,omitempty"`
+}
+
+// Validate validates this api cluster node
+func (m *APIClusterNode) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIClusterNode) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIClusterNode) UnmarshalBinary(b []byte) error {
+	var res APIClusterNode
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,62 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIHealthCheck Health check of the values of a record set
+// swagger:model apiHealthCheck
+type APIHealthCheck struct {
+
+	// HTTP request path
+	Path string `json:"path,omitempty"`
+
+	// Port to connect to
+	Port int32 `json:"port,omitempty"`
+
+	// Expected HTTP status, default is 200
+	Status int32 `json:"status,omitempty"`
+
+	// Consecutive failures before marking a value unhealthy, default is 1
+	Threshold int32 `json:"threshold,omitempty"`
+
+	// Timeout in seconds, default is 2
+	Timeout int32 `json:"timeout,omitempty"`
+
+	// Check type, one of tcp, http or none to disable it
+	Type string `json:"type,omitempty"`
+}
+
+// Validate validates this api health check
+func (m *APIHealthCheck) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIHealthCheck) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIHealthCheck) UnmarshalBinary(b []byte) error {
+	var res APIHealthCheck
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
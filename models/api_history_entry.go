@@ -0,0 +1,65 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIHistoryEntry Change of a record set
+// swagger:model apiHistoryEntry
+type APIHistoryEntry struct {
+
+	// Token, certificate, TSIG key or address of the client
+	Actor string `json:"actor,omitempty"`
+
+	// new values
+	NewValues []string `json:"new_values,omitempty"`
+
+	// old values
+	OldValues []string `json:"old_values,omitempty"`
+
+	// Version replaced by the change, empty on creation
+	PreviousVersion string `json:"previous_version,omitempty"`
+
+	// One of api, nsupdate, expiry
+	Source string `json:"source,omitempty"`
+
+	// Time of the change as Unix timestamp
+	Time int64 `json:"time,omitempty,string"`
+
+	// Version stored by the change, empty on removal
+	Version string `json:"version,omitempty"`
+}
+
+// Validate validates this api history entry
+func (m *APIHistoryEntry) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIHistoryEntry) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIHistoryEntry) UnmarshalBinary(b []byte) error {
+	var res APIHistoryEntry
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,53 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APILease Lease of ephemeral records
+// swagger:model apiLease
+type APILease struct {
+
+	// Lease duration in seconds, default to the last duration set
+	Duration int32 `json:"duration,omitempty"`
+
+	// Expiration of the records as Unix timestamp
+	Expires int64 `json:"expires,omitempty,string"`
+
+	// Lease ID
+	ID string `json:"id,omitempty"`
+}
+
+// Validate validates this api lease
+func (m *APILease) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APILease) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APILease) UnmarshalBinary(b []byte) error {
+	var res APILease
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,56 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIPrerequisite Condition checked before applying a batch
+// swagger:model apiPrerequisite
+type APIPrerequisite struct {
+
+	// One of exists (default), not_exists
+	Condition string `json:"condition,omitempty"`
+
+	// Record Name
+	Domain string `json:"domain,omitempty"`
+
+	// Value that must be in the set, any value if empty
+	IP string `json:"ip,omitempty"`
+
+	// Record Type
+	Type string `json:"type,omitempty"`
+}
+
+// Validate validates this api prerequisite
+func (m *APIPrerequisite) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIPrerequisite) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIPrerequisite) UnmarshalBinary(b []byte) error {
+	var res APIPrerequisite
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -20,33 +20,84 @@ type APIRecord struct {
 	// TTL time to live of the record
 	TTL int32 `json:"TTL,omitempty"`
 
+	// Add the record to the existing set instead of replacing it
+	Append bool `json:"append,omitempty"`
+
+	// Health check of the record set
+	Check *APIHealthCheck `json:"check,omitempty"`
+
 	// Record Name
 	Domain string `json:"domain,omitempty"`
 
 	// Expiration of the record, after which will be removed.
 	// Default is 0 for not expiring
-	Expires int32 `json:"expires,omitempty"`
+	Expires int64 `json:"expires,omitempty,string"`
 
 	// id
 	ID string `json:"id,omitempty"`
 
+	// Version (id) the stored record must match, * for any existing record.
+	// Set from the If-Match header when using the HTTP API
+	IfMatch string `json:"if_match,omitempty"`
+
 	// Record IP address
 	IP string `json:"ip,omitempty"`
 
+	// Lease duration in seconds, the record is removed unless the lease is renewed
+	Lease int32 `json:"lease,omitempty"`
+
+	// Lease ID, returned when a lease is set. Pass it to attach the record to an existing lease
+	LeaseID string `json:"lease_id,omitempty"`
+
+	// Answer policy of the record set, one of fixed, round-robin, random, weighted
+	Policy string `json:"policy,omitempty"`
+
 	// Record Type see https://github.com/miekg/dns/blob/master/types.go#L27
 	Type string `json:"type,omitempty"`
+
+	// Record Name with the internationalized labels in Unicode (U-label),
+	// domain is returned in punycode (A-label)
+	UnicodeDomain string `json:"unicode_domain,omitempty"`
+
+	// Use the address of the caller as ip, the type is set to A or AAAA by address family
+	UseCallerIP bool `json:"use_caller_ip,omitempty"`
+
+	// Weight of the record when using the weighted policy
+	Weight int32 `json:"weight,omitempty"`
 }
 
 // Validate validates this api record
 func (m *APIRecord) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateCheck(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
 	return nil
 }
 
+func (m *APIRecord) validateCheck(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Check) { // not required
+		return nil
+	}
+
+	if m.Check != nil {
+		if err := m.Check.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("check")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 // MarshalBinary interface implementation
 func (m *APIRecord) MarshalBinary() ([]byte, error) {
 	if m == nil {
@@ -0,0 +1,78 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"strconv"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIRecordList api record list
+// swagger:model apiRecordList
+type APIRecordList struct {
+
+	// records
+	Records []*APIRecordSet `json:"records,omitempty"`
+}
+
+// Validate validates this api record list
+func (m *APIRecordList) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateRecords(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *APIRecordList) validateRecords(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Records) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Records); i++ {
+		if swag.IsZero(m.Records[i]) { // not required
+			continue
+		}
+
+		if m.Records[i] != nil {
+			if err := m.Records[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("records" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIRecordList) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIRecordList) UnmarshalBinary(b []byte) error {
+	var res APIRecordList
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
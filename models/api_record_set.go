@@ -0,0 +1,125 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"strconv"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIRecordSet Records stored for a domain and type
+// swagger:model apiRecordSet
+type APIRecordSet struct {
+
+	// Health check of the record set
+	Check *APIHealthCheck `json:"check,omitempty"`
+
+	// Record Name
+	Domain string `json:"domain,omitempty"`
+
+	// Expiration of the records
+	Expires int64 `json:"expires,omitempty,string"`
+
+	// id
+	ID string `json:"id,omitempty"`
+
+	// Lease ID of the record set
+	LeaseID string `json:"lease_id,omitempty"`
+
+	// Answer policy of the record set
+	Policy string `json:"policy,omitempty"`
+
+	// Record Type
+	Type string `json:"type,omitempty"`
+
+	// Record Name with the internationalized labels in Unicode (U-label),
+	// domain is returned in punycode (A-label)
+	UnicodeDomain string `json:"unicode_domain,omitempty"`
+
+	// Records of the set
+	Values []*APIRecordValue `json:"values,omitempty"`
+}
+
+// Validate validates this api record set
+func (m *APIRecordSet) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateCheck(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateValues(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *APIRecordSet) validateCheck(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Check) { // not required
+		return nil
+	}
+
+	if m.Check != nil {
+		if err := m.Check.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("check")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *APIRecordSet) validateValues(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Values) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Values); i++ {
+		if swag.IsZero(m.Values[i]) { // not required
+			continue
+		}
+
+		if m.Values[i] != nil {
+			if err := m.Values[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("values" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIRecordSet) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIRecordSet) UnmarshalBinary(b []byte) error {
+	var res APIRecordSet
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
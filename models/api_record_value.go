@@ -0,0 +1,59 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIRecordValue A single value of a record set
+// swagger:model apiRecordValue
+type APIRecordValue struct {
+
+	// Last health check error
+	Error string `json:"error,omitempty"`
+
+	// Health check result, true if not checked
+	Healthy bool `json:"healthy,omitempty"`
+
+	// Last health check as Unix timestamp
+	LastCheck int64 `json:"last_check,omitempty,string"`
+
+	// Record value in zone file format
+	Value string `json:"value,omitempty"`
+
+	// Weight of the record when using the weighted policy
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// Validate validates this api record value
+func (m *APIRecordValue) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIRecordValue) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIRecordValue) UnmarshalBinary(b []byte) error {
+	var res APIRecordValue
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
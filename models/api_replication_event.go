@@ -0,0 +1,65 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIReplicationEvent Change sent to the replicas
+// swagger:model apiReplicationEvent
+type APIReplicationEvent struct {
+
+	// bucket
+	Bucket string `json:"bucket,omitempty"`
+
+	// deleted
+	Deleted bool `json:"deleted,omitempty"`
+
+	// key
+	Key string `json:"key,omitempty"`
+
+	// Sequence of the change on the primary, or the last one for snapshot_done and heartbeat
+	Seq uint64 `json:"seq,omitempty,string"`
+
+	// Commit time of the change as Unix timestamp in nanoseconds
+	Time int64 `json:"time,omitempty,string"`
+
+	// One of snapshot, snapshot_done, change, heartbeat
+	Type string `json:"type,omitempty"`
+
+	// value
+	Value strfmt.Base64 `json:"value,omitempty"`
+}
+
+// Validate validates this api replication event
+func (m *APIReplicationEvent) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIReplicationEvent) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIReplicationEvent) UnmarshalBinary(b []byte) error {
+	var res APIReplicationEvent
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
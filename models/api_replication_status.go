@@ -0,0 +1,68 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIReplicationStatus api replication status
+// swagger:model apiReplicationStatus
+type APIReplicationStatus struct {
+
+	// True if the replica is streaming from the primary
+	Connected bool `json:"connected,omitempty"`
+
+	// Delay in milliseconds between the commit on the primary and the apply on the replica
+	LagMs int64 `json:"lag_ms,omitempty,string"`
+
+	// Last message from the primary as Unix timestamp
+	LastContact int64 `json:"last_contact,omitempty,string"`
+
+	// Address of the primary, for replicas
+	Primary string `json:"primary,omitempty"`
+
+	// Last change committed on the primary, as known by the replica
+	PrimarySeq uint64 `json:"primary_seq,omitempty,string"`
+
+	// Number of replicas streaming from the primary
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// One of primary, replica
+	Role string `json:"role,omitempty"`
+
+	// Last change committed on the primary, or applied on the replica
+	Seq uint64 `json:"seq,omitempty,string"`
+}
+
+// Validate validates this api replication status
+func (m *APIReplicationStatus) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIReplicationStatus) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIReplicationStatus) UnmarshalBinary(b []byte) error {
+	var res APIReplicationStatus
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,56 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIRestoreRequest api restore request
+// swagger:model apiRestoreRequest
+type APIRestoreRequest struct {
+
+	// domain
+	Domain string `json:"domain,omitempty"`
+
+	// Version the stored record must match, * for any existing record
+	IfMatch string `json:"if_match,omitempty"`
+
+	// type
+	Type string `json:"type,omitempty"`
+
+	// Version to restore
+	Version string `json:"version,omitempty"`
+}
+
+// Validate validates this api restore request
+func (m *APIRestoreRequest) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIRestoreRequest) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIRestoreRequest) UnmarshalBinary(b []byte) error {
+	var res APIRestoreRequest
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
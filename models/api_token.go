@@ -0,0 +1,59 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APIToken API token
+// swagger:model apiToken
+type APIToken struct {
+
+	// Creation as Unix timestamp
+	Created int64 `json:"created,omitempty,string"`
+
+	// Token ID
+	ID string `json:"id,omitempty"`
+
+	// Token description
+	Name string `json:"name,omitempty"`
+
+	// Scopes, one of read, write, write:<domain suffix>, admin
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Token secret, returned only on creation
+	Token string `json:"token,omitempty"`
+}
+
+// Validate validates this api token
+func (m *APIToken) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APIToken) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APIToken) UnmarshalBinary(b []byte) error {
+	var res APIToken
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,78 @@
+package models
+
+// This file was generated by the swagger tool.
+// Editing this file might prove futile when you re-run the swagger generate command
+
+import (
+	"strconv"
+
+	strfmt "github.com/go-openapi/strfmt"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/swag"
+)
+
+// APITokenList api token list
+// swagger:model apiTokenList
+type APITokenList struct {
+
+	// tokens
+	Tokens []*APIToken `json:"tokens,omitempty"`
+}
+
+// Validate validates this api token list
+func (m *APITokenList) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateTokens(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *APITokenList) validateTokens(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Tokens) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Tokens); i++ {
+		if swag.IsZero(m.Tokens[i]) { // not required
+			continue
+		}
+
+		if m.Tokens[i] != nil {
+			if err := m.Tokens[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("tokens" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *APITokenList) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *APITokenList) UnmarshalBinary(b []byte) error {
+	var res APITokenList
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
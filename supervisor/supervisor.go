@@ -0,0 +1,150 @@
+package supervisor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Server is a component serving requests until shut down
+type Server interface {
+	// Serve block until the server is shut down, returns nil when shut down
+	Serve() error
+	// Shutdown stop accepting requests and wait the in-flight ones, until
+	// the context is done
+	Shutdown(ctx context.Context) error
+}
+
+type namedServer struct {
+	name   string
+	server Server
+}
+
+//Supervisor run the servers and the background tasks of the service. When
+// a component fails or the context is done, all of them are stopped
+type Supervisor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	servers []namedServer
+	failed  *Error
+
+	wg sync.WaitGroup
+}
+
+//New return a supervisor stopped when the context is done
+func New(ctx context.Context) *Supervisor {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Supervisor{ctx: ctx, cancel: cancel}
+}
+
+//Context is done when the supervisor stops
+func (s *Supervisor) Context() context.Context {
+	return s.ctx
+}
+
+//Stop the components, eg. when one fails to start
+func (s *Supervisor) Stop() {
+	s.cancel()
+}
+
+// fail record the first failure and stop the components
+func (s *Supervisor) fail(name string, err error) {
+
+	log.Errorf("%s failed: %s", name, err.Error())
+
+	s.mu.Lock()
+	if s.failed == nil {
+		s.failed = &Error{Name: name, Err: err}
+	}
+	s.mu.Unlock()
+
+	s.cancel()
+}
+
+//Serve run a server in background, shut down when the supervisor stops
+func (s *Supervisor) Serve(name string, server Server) {
+
+	s.mu.Lock()
+	s.servers = append(s.servers, namedServer{name: name, server: server})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		log.Debugf("Serving %s", name)
+		if err := server.Serve(); err != nil {
+			s.fail(name, err)
+		}
+	}()
+}
+
+//Go run a background task until the context is done, an error stops the supervisor
+func (s *Supervisor) Go(name string, task func(ctx context.Context) error) {
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := task(s.ctx); err != nil && err != context.Canceled {
+			s.fail(name, err)
+		}
+	}()
+}
+
+//Wait until the supervisor stops, then shut down the servers in the reverse
+// order of start, waiting their in-flight requests up to timeout. Returns the
+// error of the first failed component
+func (s *Supervisor) Wait(timeout time.Duration) error {
+
+	<-s.ctx.Done()
+
+	log.Infof("Stopping, waiting the in-flight requests up to %s", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	s.mu.Lock()
+	servers := s.servers
+	s.mu.Unlock()
+
+	for i := len(servers) - 1; i >= 0; i-- {
+		log.Debugf("Stopping %s", servers[i].name)
+		if err := servers[i].server.Shutdown(ctx); err != nil {
+			log.Warnf("Failed to stop %s: %s", servers[i].name, err.Error())
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warnf("Shutdown timeout of %s expired, stopping anyway", timeout)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failed != nil {
+		return s.failed
+	}
+
+	return nil
+}
+
+// Error of a failed component
+type Error struct {
+	Name string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Name + " failed: " + e.Err.Error()
+}